@@ -0,0 +1,297 @@
+package generate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFile is the name of the configuration file looked for at the module
+// root when no inputs are given.
+const ConfigFile = "ridicule.yaml"
+
+// Config describes every mock to generate in a project, e.g.
+//
+//	defaults:
+//	  header: true
+//	  exclude: ["*Factory"]
+//	packages:
+//	  - path: ./store
+//	    interfaces: [Store]
+//	  - path: ./internal/...
+//	    header: false
+type Config struct {
+	Defaults ConfigOptions   `yaml:"defaults"`
+	Packages []ConfigPackage `yaml:"packages"`
+}
+
+// ConfigOptions are the options that can be set globally, under defaults, and
+// overridden per package. Unset options fall back to the defaults and then to
+// the flag defaults.
+type ConfigOptions struct {
+	Header      *bool    `yaml:"header"`
+	PerFile     *bool    `yaml:"perFile"`
+	Types       *bool    `yaml:"types"`
+	Flatten     *bool    `yaml:"flatten"`
+	Constructor *bool    `yaml:"constructor"`
+	Expecter    *bool    `yaml:"expecter"`
+	Assert      *bool    `yaml:"assert"`
+	Interfaces  []string `yaml:"interfaces"`
+	Exclude     []string `yaml:"exclude"`
+	OutPkg      string   `yaml:"outPkg"`
+	OutDir      string   `yaml:"outDir"`
+	Naming      string   `yaml:"name"`
+}
+
+// ConfigPackage configures the mocks of a single package, or of every package
+// matching a ./... pattern. Paths are relative to the configuration file.
+type ConfigPackage struct {
+	Path          string `yaml:"path"`
+	Out           string `yaml:"out"`
+	ConfigOptions `yaml:",inline"`
+}
+
+// LoadConfig reads and validates the configuration file at path.
+func LoadConfig(path string) (*Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &Config{}
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(config); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if err := config.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return config, nil
+}
+
+// Validate checks the configuration, returning the first problem found.
+func (c *Config) Validate() error {
+	if len(c.Packages) == 0 {
+		return errors.New("no packages configured")
+	}
+
+	if err := c.Defaults.validate(); err != nil {
+		return fmt.Errorf("defaults: %w", err)
+	}
+
+	seen := map[string]bool{}
+	for i, pkg := range c.Packages {
+		if strings.TrimSpace(pkg.Path) == "" {
+			return fmt.Errorf("packages[%d]: path is required", i)
+		}
+
+		path := filepath.Clean(pkg.Path)
+		if seen[path] {
+			return fmt.Errorf("packages[%d]: %s is configured more than once", i, pkg.Path)
+		}
+		seen[path] = true
+
+		if pkg.Out != "" && isPattern(pkg.Path) {
+			return fmt.Errorf("packages[%d]: out can't be used with the pattern %s", i, pkg.Path)
+		}
+
+		if merged := pkg.merge(c.Defaults); pkg.Out != "" && merged.PerFile != nil && *merged.PerFile {
+			return fmt.Errorf("packages[%d]: out can't be used with perFile", i)
+		}
+
+		if err := pkg.ConfigOptions.validate(); err != nil {
+			return fmt.Errorf("packages[%d]: %w", i, err)
+		}
+	}
+
+	return nil
+}
+
+func (o ConfigOptions) validate() error {
+	for _, pattern := range append(append([]string{}, o.Interfaces...), o.Exclude...) {
+		if _, err := matchName(pattern, ""); err != nil {
+			return err
+		}
+	}
+
+	if o.OutPkg != "" && !token.IsIdentifier(o.OutPkg) {
+		return fmt.Errorf("invalid outPkg %s", o.OutPkg)
+	}
+
+	if o.Naming != "" {
+		if _, err := MockName(o.Naming, "Interface"); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// merge returns the package's options with any unset falling back to those
+// in defaults.
+func (p ConfigPackage) merge(defaults ConfigOptions) ConfigOptions {
+	merged := p.ConfigOptions
+	if merged.Header == nil {
+		merged.Header = defaults.Header
+	}
+
+	if merged.PerFile == nil {
+		merged.PerFile = defaults.PerFile
+	}
+
+	if merged.Types == nil {
+		merged.Types = defaults.Types
+	}
+
+	if merged.Flatten == nil {
+		merged.Flatten = defaults.Flatten
+	}
+
+	if merged.Constructor == nil {
+		merged.Constructor = defaults.Constructor
+	}
+
+	if merged.Expecter == nil {
+		merged.Expecter = defaults.Expecter
+	}
+
+	if merged.Assert == nil {
+		merged.Assert = defaults.Assert
+	}
+
+	if merged.Interfaces == nil {
+		merged.Interfaces = defaults.Interfaces
+	}
+
+	if merged.Exclude == nil {
+		merged.Exclude = defaults.Exclude
+	}
+
+	if merged.OutPkg == "" {
+		merged.OutPkg = defaults.OutPkg
+	}
+
+	if merged.OutDir == "" {
+		merged.OutDir = defaults.OutDir
+	}
+
+	if merged.Naming == "" {
+		merged.Naming = defaults.Naming
+	}
+
+	return merged
+}
+
+// Options returns the options to generate each configured package with,
+// resolving paths relative to dir and applying them on top of base. Options
+// the configuration leaves unset keep their value from base.
+func (c *Config) Options(dir string, base Options) []*Options {
+	ret := make([]*Options, 0, len(c.Packages))
+	for _, pkg := range c.Packages {
+		merged := pkg.merge(c.Defaults)
+
+		opts := base
+		if merged.Header != nil {
+			opts.Header = *merged.Header
+		}
+
+		if merged.PerFile != nil {
+			opts.PerFile = *merged.PerFile
+		}
+
+		if merged.Types != nil {
+			opts.Types = *merged.Types
+		}
+
+		if merged.Flatten != nil {
+			opts.Flatten = *merged.Flatten
+		}
+
+		if merged.Constructor != nil {
+			opts.Constructor = *merged.Constructor
+		}
+
+		if merged.Expecter != nil {
+			opts.Expecter = *merged.Expecter
+		}
+
+		if merged.Assert != nil {
+			opts.Assert = *merged.Assert
+		}
+
+		if len(merged.Interfaces) > 0 {
+			opts.Interfaces = merged.Interfaces
+		}
+
+		if len(merged.Exclude) > 0 {
+			opts.Exclude = merged.Exclude
+		}
+
+		if merged.OutPkg != "" {
+			opts.OutPkg = merged.OutPkg
+		}
+
+		if merged.OutDir != "" {
+			opts.OutDir = merged.OutDir
+		}
+
+		if merged.Naming != "" {
+			opts.Naming = merged.Naming
+		}
+
+		path := filepath.Join(dir, pkg.Path)
+		if isPattern(pkg.Path) {
+			opts.Patterns = []string{path}
+		} else {
+			opts.Pkg = path
+		}
+
+		if pkg.Out != "" {
+			opts.Out = filepath.Join(dir, pkg.Out)
+		}
+
+		ret = append(ret, &opts)
+	}
+
+	return ret
+}
+
+// FindConfig looks for the configuration file at the root of the module
+// containing dir, returning an empty string if there isn't one.
+func FindConfig(dir string) string {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return ""
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			path := filepath.Join(dir, ConfigFile)
+			if _, err := os.Stat(path); err == nil {
+				return path
+			}
+
+			return ""
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+func isPattern(path string) bool {
+	return path == "..." || strings.HasSuffix(path, "/...")
+}
@@ -0,0 +1,85 @@
+package generate
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n",
+		ConfigFile: `defaults:
+  header: true
+  exclude: ["*Factory"]
+packages:
+  - path: ./store
+    out: ./store/mocks_mock.go
+    interfaces: [Store]
+  - path: ./internal/...
+    header: false
+    perFile: true
+`,
+	})
+
+	path := FindConfig(filepath.Join(dir, "store"))
+	assert.Equal(t, filepath.Join(dir, ConfigFile), path)
+
+	config, err := LoadConfig(path)
+	require.NoError(t, err)
+
+	opts := config.Options(dir, Options{Flatten: true})
+	assert.Equal(t, []*Options{
+		{
+			Pkg:        filepath.Join(dir, "store"),
+			Out:        filepath.Join(dir, "store", "mocks_mock.go"),
+			Header:     true,
+			Flatten:    true,
+			Interfaces: []string{"Store"},
+			Exclude:    []string{"*Factory"},
+		},
+		{
+			Patterns: []string{filepath.Join(dir, "internal", "...")},
+			PerFile:  true,
+			Flatten:  true,
+			Exclude:  []string{"*Factory"},
+		},
+	}, opts)
+	// Options the configuration leaves unset keep those of the flags
+	opts = config.Options(dir, Options{Interfaces: []string{"Base"}, OutPkg: "mocks", Header: true})
+	assert.Equal(t, []string{"Store"}, opts[0].Interfaces)
+	assert.Equal(t, []string{"Base"}, opts[1].Interfaces)
+	assert.Equal(t, "mocks", opts[1].OutPkg)
+	assert.False(t, opts[1].Header)
+}
+
+func TestLoadConfigErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "empty", content: "", err: "no packages configured"},
+		{name: "unknown field", content: "packages:\n  - path: ./a\n    outt: x.go\n", err: "field outt not found"},
+		{name: "missing path", content: "packages:\n  - out: x.go\n", err: "packages[0]: path is required"},
+		{name: "duplicate", content: "packages:\n  - path: ./a\n  - path: a\n", err: "packages[1]: a is configured more than once"},
+		{name: "out with pattern", content: "packages:\n  - path: ./...\n    out: x.go\n", err: "packages[0]: out can't be used with the pattern ./..."},
+		{name: "out with per file", content: "defaults:\n  perFile: true\npackages:\n  - path: ./a\n    out: x.go\n", err: "packages[0]: out can't be used with perFile"},
+		{name: "invalid default pattern", content: "defaults:\n  exclude: [\"[a\"]\npackages:\n  - path: ./a\n", err: "defaults: invalid interface pattern [a"},
+		{name: "invalid pattern", content: "packages:\n  - path: ./a\n    interfaces: [\"/(/\"]\n", err: "packages[0]: invalid interface pattern /(/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeFiles(t, dir, map[string]string{ConfigFile: tt.content})
+
+			_, err := LoadConfig(filepath.Join(dir, ConfigFile))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"go/token"
+	"slices"
+	"strings"
+)
+
+// Severity is how serious a Diagnostic is.
+type Severity int
+
+const (
+	// SeverityWarning is a construct that's mocked, but probably not as
+	// intended.
+	SeverityWarning Severity = iota
+	// SeverityError is a construct that can't be mocked.
+	SeverityError
+)
+
+func (s Severity) String() string {
+	if s == SeverityError {
+		return "error"
+	}
+
+	return "warning"
+}
+
+// Diagnostic is a problem found with an interface while generating its mock.
+type Diagnostic struct {
+	Pos       token.Position
+	Severity  Severity
+	Interface string
+	// Method is empty for problems with the interface as a whole
+	Method string
+	Msg    string
+}
+
+// String formats the diagnostic as file:line:col: severity: msg, as compilers
+// do, so editors and CI annotators can parse it.
+func (d Diagnostic) String() string {
+	var b strings.Builder
+	b.WriteString(d.position())
+	b.WriteString(d.Severity.String())
+	b.WriteString(": ")
+	b.WriteString(d.subject())
+	b.WriteString(d.Msg)
+
+	return b.String()
+}
+
+// position returns the position prefix of the diagnostic, if it has one.
+func (d Diagnostic) position() string {
+	if !d.Pos.IsValid() {
+		return ""
+	}
+
+	return d.Pos.String() + ": "
+}
+
+// subject returns the interface and method prefix of the diagnostic, if it has
+// one.
+func (d Diagnostic) subject() string {
+	switch {
+	case d.Interface == "":
+		return ""
+	case d.Method == "":
+		return d.Interface + ": "
+	default:
+		return d.Interface + "." + d.Method + ": "
+	}
+}
+
+// Diagnostics returns the errors returned by Generate as error severity
+// diagnostics, one for each ParseError and UnsupportedTypeError. Any other
+// errors become a diagnostic without a position.
+func Diagnostics(err error) []Diagnostic {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *ParseError:
+		return []Diagnostic{e.Diagnostic()}
+	case *UnsupportedTypeError:
+		return []Diagnostic{e.Diagnostic()}
+	case interface{ Unwrap() []error }:
+		diags := make([]Diagnostic, 0)
+		for _, err := range e.Unwrap() {
+			diags = append(diags, Diagnostics(err)...)
+		}
+
+		return diags
+	}
+
+	// Keep the context of wrapped errors that don't have a position
+	if diags := Diagnostics(errors.Unwrap(err)); slices.ContainsFunc(diags, func(d Diagnostic) bool {
+		return d.Pos.IsValid()
+	}) {
+		return diags
+	}
+
+	return []Diagnostic{{Severity: SeverityError, Msg: err.Error()}}
+}
+
+// mockMembers are the members of mock.Mock that the methods of a mock clash
+// with. String is left out as mocking fmt.Stringer is common and harmless.
+var mockMembers = []string{
+	"Calls", "ExpectedCalls", "Test", "TestData", "On", "Called", "MethodCalled",
+	"AssertExpectations", "AssertNumberOfCalls", "AssertCalled", "AssertNotCalled",
+	"IsMethodCallable",
+}
+
+// lint returns warnings for the interfaces of tempData that are mocked, but
+// probably not as intended. external reports whether the mocks are written to
+// another package.
+func lint(tempData *TemplateData, external bool) []Diagnostic {
+	diags := make([]Diagnostic, 0)
+	for _, inter := range tempData.Interfaces {
+		warn := func(pos token.Position, method, format string, args ...any) {
+			diags = append(diags, Diagnostic{
+				Pos:       pos,
+				Severity:  SeverityWarning,
+				Interface: inter.Name,
+				Method:    method,
+				Msg:       fmt.Sprintf(format, args...),
+			})
+		}
+
+		if inter.Constraint {
+			warn(inter.Pos, "", "interface is only usable as a type constraint, so its mock can't implement it")
+		} else if len(inter.Funcs) == 0 && len(inter.Embedded) == 0 {
+			warn(inter.Pos, "", "interface has no methods")
+		}
+
+		if refs := unexportedRefs(inter.Generics, tempData.SourcePackage); external && len(refs) > 0 {
+			warn(inter.Pos, "", "type parameters refer to unexported %s, which a mock in another package can't", strings.Join(refs, ", "))
+		}
+
+		for _, fun := range inter.Funcs {
+			refs := unexportedRefs(slices.Concat(fun.Params, fun.Return), tempData.SourcePackage)
+			if external && len(refs) > 0 {
+				warn(fun.Pos, fun.Name, "method refers to unexported %s, which a mock in another package can't", strings.Join(refs, ", "))
+			}
+
+			switch {
+			case slices.Contains(mockMembers, fun.Name):
+				warn(fun.Pos, fun.Name, "method clashes with mock.Mock's %s", fun.Name)
+			case fun.Name == "EXPECT" && tempData.Expecter:
+				warn(fun.Pos, fun.Name, "method clashes with the generated EXPECT")
+			case external && !token.IsExported(fun.Name):
+				warn(fun.Pos, fun.Name, "unexported method can't be implemented by a mock in another package")
+			}
+		}
+	}
+
+	return diags
+}
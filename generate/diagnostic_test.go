@@ -0,0 +1,96 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+type Store interface {
+	Get(key string) string
+	On(event string)
+	close()
+}
+
+type Number interface {
+	~int | ~float64
+}
+
+//ridicule:mock
+type Ordered interface {
+	~int | ~string
+}
+
+type Empty interface{}
+
+type Skipped interface {
+	On(event string)
+}
+
+type config struct{}
+
+type Loader interface {
+	Load(c config) (*config, error)
+}
+`,
+	})
+
+	for _, types := range []bool{false, true} {
+		diags := make([]string, 0)
+		opts := DefaultOptions()
+		opts.Pkg = dir
+		opts.Types = types
+		opts.OutPkg = "mocks"
+		opts.Exclude = []string{"Skipped"}
+		opts.Report = func(d Diagnostic) {
+			diags = append(diags, d.String())
+		}
+
+		files, err := Generate(context.Background(), opts)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+		assert.NotContains(t, string(files[0].Content), "var _ foo.Loader")
+		assert.NotContains(t, string(files[0].Content), "MockNumber")
+		assert.Contains(t, string(files[0].Content), "MockOrdered")
+
+		path := filepath.Join(dir, "foo.go")
+		assert.Equal(t, []string{
+			path + ":5:2: warning: Store.On: method clashes with mock.Mock's On",
+			path + ":6:2: warning: Store.close: unexported method can't be implemented by a mock in another package",
+			path + ":14:6: warning: Ordered: interface is only usable as a type constraint, so its mock can't implement it",
+			path + ":18:6: warning: Empty: interface has no methods",
+			path + ":27:2: warning: Loader.Load: method refers to unexported config, which a mock in another package can't",
+		}, diags, "types: %t", types)
+	}
+}
+
+func TestDiagnostics(t *testing.T) {
+	pos := token.Position{Filename: "foo.go", Line: 4, Column: 10}
+	err := fmt.Errorf("parsing package 'foo': %w", errors.Join(
+		&ParseError{Pos: pos, Msg: "expected ')'"},
+		&UnsupportedTypeError{Pos: pos, Interface: "Store", Type: "*ast.BadExpr"},
+	))
+
+	diags := Diagnostics(err)
+	require.Len(t, diags, 2)
+	assert.Equal(t, "foo.go:4:10: error: expected ')'", diags[0].String())
+	assert.Equal(t, "foo.go:4:10: error: Store: unsupported type *ast.BadExpr", diags[1].String())
+
+	err = fmt.Errorf("loading config: %w", errors.New("no such file"))
+	assert.Equal(t, []Diagnostic{{Severity: SeverityError, Msg: "loading config: no such file"}}, Diagnostics(err))
+	assert.Equal(t, "error: loading config: no such file", Diagnostics(err)[0].String())
+
+	assert.Empty(t, Diagnostics(nil))
+}
@@ -0,0 +1,125 @@
+package generate
+
+import (
+	"go/ast"
+	"path/filepath"
+	"strings"
+)
+
+const directivePrefix = "//ridicule:"
+
+// applyDirectives reads the //ridicule: directives from the doc comments of an
+// interface declaration:
+//
+//	//ridicule:mock            always mock the interface, ignoring any filters
+//	//ridicule:skip            never mock the interface
+//	//ridicule:name=FakeStore  name the mock FakeStore, or by a naming template
+//	//ridicule:out=store.go    write the mock to store.go, relative to the mocks
+func applyDirectives(inter *Interface, docs ...*ast.CommentGroup) {
+	for _, doc := range docs {
+		if doc == nil {
+			continue
+		}
+
+		for _, c := range doc.List {
+			if !strings.HasPrefix(c.Text, directivePrefix) {
+				continue
+			}
+
+			key, value, _ := strings.Cut(strings.TrimSpace(strings.TrimPrefix(c.Text, directivePrefix)), "=")
+			switch key {
+			case "mock":
+				inter.Mock = true
+			case "skip":
+				inter.Skip = true
+			case "name":
+				inter.MockName = value
+			case "out":
+				inter.Out = value
+			}
+		}
+	}
+}
+
+// typeSpecDocs returns the doc comments of a type spec, including those of its
+// declaration when the spec isn't grouped.
+func typeSpecDocs(gen *ast.GenDecl, ts *ast.TypeSpec) []*ast.CommentGroup {
+	if gen.Lparen.IsValid() {
+		return []*ast.CommentGroup{ts.Doc}
+	}
+
+	return []*ast.CommentGroup{gen.Doc, ts.Doc}
+}
+
+type mockFile struct {
+	path string
+	data *TemplateData
+}
+
+// splitByOut splits tempData by the out directives of its interfaces, those
+// without one being written to out and the rest relative to dir. Files without
+// any interfaces are dropped.
+func splitByOut(tempData *TemplateData, dir, out string) []*mockFile {
+	files := []*mockFile{{path: out, data: copyTemplateData(tempData)}}
+	for _, inter := range tempData.Interfaces {
+		path := out
+		if inter.Out != "" {
+			path = filepath.Join(dir, inter.Out)
+		}
+
+		var file *mockFile
+		for _, f := range files {
+			if f.path == path {
+				file = f
+			}
+		}
+
+		if file == nil {
+			file = &mockFile{path: path, data: copyTemplateData(tempData)}
+			files = append(files, file)
+		}
+
+		file.data.Interfaces = append(file.data.Interfaces, inter)
+	}
+
+	ret := make([]*mockFile, 0, len(files))
+	for _, f := range files {
+		if len(f.data.Interfaces) > 0 {
+			ret = append(ret, f)
+		}
+	}
+
+	return ret
+}
+
+// mergeMockFiles merges files written to the same path, keeping the order in
+// which each path first appears.
+func mergeMockFiles(files []*mockFile) []*mockFile {
+	ret := make([]*mockFile, 0, len(files))
+	for _, f := range files {
+		merged := false
+		for _, r := range ret {
+			if r.path == f.path {
+				data := MergeTemplateData([]*TemplateData{r.data, f.data})
+				data.Header, data.Constructor, data.Expecter, data.Assert = r.data.Header, r.data.Constructor, r.data.Expecter, r.data.Assert
+				r.data = data
+				merged = true
+			}
+		}
+
+		if !merged {
+			ret = append(ret, f)
+		}
+	}
+
+	return ret
+}
+
+// copyTemplateData copies everything but the interfaces of tempData.
+func copyTemplateData(tempData *TemplateData) *TemplateData {
+	cp := *tempData
+	cp.Interfaces = make([]*Interface, 0)
+	cp.Imports = append([]string{}, tempData.Imports...)
+
+	return &cp
+}
@@ -0,0 +1,69 @@
+package generate
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDirectives(t *testing.T) {
+	testFileSrc := `package foo
+
+	// Store stores things
+	//
+	//ridicule:name=FakeStore
+	//ridicule:out=mocks/store.go
+	type Store interface {
+		Put()
+	}
+
+	//ridicule:skip
+	type Skipped interface {
+		Skip()
+	}
+
+	type (
+		//ridicule:mock
+		Forced interface {
+			Force()
+		}
+
+		Plain interface {
+			Plain()
+		}
+	)
+	`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", testFileSrc, parser.ParseComments)
+	require.NoError(t, err)
+
+	tempData := Parse(f)
+	require.Len(t, tempData.Interfaces, 4)
+
+	store, skipped, forced, plain := tempData.Interfaces[0], tempData.Interfaces[1], tempData.Interfaces[2], tempData.Interfaces[3]
+	assert.Equal(t, "FakeStore", store.MockName)
+	assert.Equal(t, "mocks/store.go", store.Out)
+	assert.True(t, skipped.Skip)
+	assert.True(t, forced.Mock)
+	assert.Equal(t, &Interface{Name: "Plain", Funcs: plain.Funcs}, plain)
+
+	require.NoError(t, Filter(tempData, []string{"Store", "Skipped"}, nil))
+	assert.Equal(t, []*Interface{store, forced}, tempData.Interfaces)
+
+	files := splitByOut(tempData, "src", filepath.Join("src", "foo_mock.go"))
+	require.Len(t, files, 2)
+	assert.Equal(t, filepath.Join("src", "foo_mock.go"), files[0].path)
+	assert.Equal(t, []*Interface{forced}, files[0].data.Interfaces)
+	assert.Equal(t, filepath.Join("src", "mocks", "store.go"), files[1].path)
+	assert.Equal(t, []*Interface{store}, files[1].data.Interfaces)
+
+	ret, err := writeMock(files[1].data, NewFileWriter(), files[1].path)
+	require.NoError(t, err)
+	assert.Contains(t, string(ret), "type FakeStore struct")
+	assert.Contains(t, string(ret), "func (mock *FakeStore) Put()")
+}
@@ -0,0 +1,120 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// ParseError is a syntax or type error in the source being mocked.
+type ParseError struct {
+	Pos token.Position
+	Msg string
+}
+
+func (e *ParseError) Error() string {
+	return e.Diagnostic().position() + e.Msg
+}
+
+// Diagnostic returns the error as an error severity Diagnostic.
+func (e *ParseError) Diagnostic() Diagnostic {
+	return Diagnostic{Pos: e.Pos, Severity: SeverityError, Msg: e.Msg}
+}
+
+// UnsupportedTypeError is a type in an interface that can't be rendered in its
+// mock, such as an *ast.BadExpr.
+type UnsupportedTypeError struct {
+	Pos       token.Position
+	Interface string
+	// Method is empty for the interface's type parameters and embedded types
+	Method string
+	Type   string
+}
+
+func (e *UnsupportedTypeError) Error() string {
+	d := e.Diagnostic()
+	return d.position() + d.subject() + d.Msg
+}
+
+// Diagnostic returns the error as an error severity Diagnostic.
+func (e *UnsupportedTypeError) Diagnostic() Diagnostic {
+	return Diagnostic{
+		Pos:       e.Pos,
+		Severity:  SeverityError,
+		Interface: e.Interface,
+		Method:    e.Method,
+		Msg:       "unsupported type " + e.Type,
+	}
+}
+
+// TemplateError is a failure to render the mocks to be written to Path, which
+// usually means the generated code isn't valid Go.
+type TemplateError struct {
+	Path string
+	Err  error
+}
+
+func (e *TemplateError) Error() string {
+	return fmt.Sprintf("rendering %s: %s", e.Path, e.Err)
+}
+
+func (e *TemplateError) Unwrap() error {
+	return e.Err
+}
+
+// parseErrors converts the errors returned by go/parser into ParseErrors,
+// leaving any others alone.
+func parseErrors(err error) error {
+	var list scanner.ErrorList
+	if !errors.As(err, &list) {
+		return err
+	}
+
+	errs := make([]error, 0, len(list))
+	for _, e := range list {
+		errs = append(errs, &ParseError{Pos: e.Pos, Msg: e.Msg})
+	}
+
+	return errors.Join(errs...)
+}
+
+// packageError converts an error loading a package into a ParseError.
+func packageError(e packages.Error) *ParseError {
+	return &ParseError{Pos: parsePosition(e.Pos), Msg: e.Msg}
+}
+
+// parsePosition parses a position formatted as file:line:col, file:line or
+// file, as go/packages reports them.
+func parsePosition(s string) token.Position {
+	pos := token.Position{Filename: s}
+	if s == "" || s == "-" {
+		return token.Position{}
+	}
+
+	for _, field := range []*int{&pos.Column, &pos.Line} {
+		i := strings.LastIndex(pos.Filename, ":")
+		if i < 0 {
+			break
+		}
+
+		n, err := strconv.Atoi(pos.Filename[i+1:])
+		if err != nil {
+			break
+		}
+
+		*field = n
+		pos.Filename = pos.Filename[:i]
+	}
+
+	// Only a line was given
+	if pos.Line == 0 && pos.Column != 0 {
+		pos.Line, pos.Column = pos.Column, 0
+	}
+
+	return pos
+}
@@ -0,0 +1,102 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"testing"
+	"text/template"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseError(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+type Store interface {
+	Get(key string) (string, error
+}
+`,
+	})
+
+	for _, opts := range []Options{
+		{In: filepath.Join(dir, "foo.go")},
+		{Pkg: dir},
+		{Pkg: dir, Types: true},
+	} {
+		_, err := Generate(context.Background(), opts)
+
+		var parseErr *ParseError
+		require.ErrorAs(t, err, &parseErr)
+		assert.Equal(t, filepath.Join(dir, "foo.go"), parseErr.Pos.Filename)
+		assert.Equal(t, 4, parseErr.Pos.Line)
+		assert.NotZero(t, parseErr.Pos.Column)
+	}
+}
+
+func TestUnsupportedTypeError(t *testing.T) {
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "foo.go", `package foo
+
+type Store interface {
+	Get(key string) string
+}
+`, 0)
+	require.NoError(t, err)
+
+	// Not something go/parser produces for a valid file
+	ast.Inspect(f, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && len(field.Names) == 1 && field.Names[0].Name == "key" {
+			field.Type = &ast.BadExpr{From: field.Type.Pos(), To: field.Type.End()}
+		}
+
+		return true
+	})
+
+	tempData, err := ParseFile(fset, f)
+	require.NotNil(t, tempData)
+
+	var unsupportedErr *UnsupportedTypeError
+	require.ErrorAs(t, err, &unsupportedErr)
+	assert.Equal(t, "*ast.BadExpr", unsupportedErr.Type)
+	assert.Equal(t, "Store", unsupportedErr.Interface)
+	assert.Equal(t, "Get", unsupportedErr.Method)
+	assert.Equal(t, "foo.go:4:10: Store.Get: unsupported type *ast.BadExpr", unsupportedErr.Error())
+	assert.Equal(t, "foo.go:4:10: error: Store.Get: unsupported type *ast.BadExpr", unsupportedErr.Diagnostic().String())
+}
+
+func TestTemplateError(t *testing.T) {
+	writer := NewFileWriter()
+	writer.template = template.Must(template.New("mock.tmpl").Parse("package {{.Package}}\n\nfunc {"))
+
+	_, err := writer.Render("foo_mock.go", &TemplateData{Package: "foo"})
+
+	var templateErr *TemplateError
+	require.ErrorAs(t, err, &templateErr)
+	assert.Equal(t, "foo_mock.go", templateErr.Path)
+	assert.NotNil(t, errors.Unwrap(err))
+}
+
+func TestParsePosition(t *testing.T) {
+	tests := []struct {
+		pos  string
+		want token.Position
+	}{
+		{"foo.go:4:10", token.Position{Filename: "foo.go", Line: 4, Column: 10}},
+		{"foo.go:4", token.Position{Filename: "foo.go", Line: 4}},
+		{"foo.go", token.Position{Filename: "foo.go"}},
+		{"", token.Position{}},
+		{"-", token.Position{}},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, parsePosition(tt.pos), tt.pos)
+	}
+}
@@ -0,0 +1,146 @@
+package generate
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Filter removes the interfaces from tempData that don't match any of the
+// include patterns, when there are some, or that match any of the exclude
+// patterns. Patterns are globs, e.g. Store*, unless wrapped in slashes, e.g.
+// /^(Reader|Writer)$/, in which case they are regular expressions. Interfaces
+// with a //ridicule:skip directive are always removed and those with a
+// //ridicule:mock directive always kept. Interfaces only usable as type
+// constraints are removed unless they have a //ridicule:mock directive, as no
+// mock can implement them. Interfaces embedded by those kept are kept too, as
+// their mocks embed the embedded interfaces' mocks when not flattened.
+func Filter(tempData *TemplateData, include, exclude []string) error {
+	return filter(tempData, tempData.Interfaces, include, exclude)
+}
+
+// filter filters tempData as Filter does, keeping the interfaces embedded by
+// those kept from any of known, which should hold every interface of the
+// package.
+func filter(tempData *TemplateData, known []*Interface, include, exclude []string) error {
+	byName := map[string]*Interface{}
+	for _, inter := range known {
+		byName[inter.Name] = inter
+	}
+
+	kept := map[string]bool{}
+	var keep func(inter *Interface)
+	keep = func(inter *Interface) {
+		if kept[inter.Name] {
+			return
+		}
+
+		kept[inter.Name] = true
+		for _, e := range inter.Embedded {
+			name, _ := splitTypeArgs(strings.TrimPrefix(e, "*"))
+			if embedded, ok := byName[name]; ok {
+				keep(embedded)
+			}
+		}
+	}
+
+	for _, inter := range known {
+		if !inter.Mock && constraint(inter, byName, map[string]bool{}) {
+			continue
+		}
+
+		ok, err := selected(inter, include, exclude)
+		if err != nil {
+			return err
+		}
+
+		if ok {
+			keep(inter)
+		}
+	}
+
+	interfaces := make([]*Interface, 0)
+	for _, inter := range tempData.Interfaces {
+		if kept[inter.Name] {
+			interfaces = append(interfaces, inter)
+		}
+	}
+
+	tempData.Interfaces = interfaces
+	return nil
+}
+
+// constraint reports whether inter, or any interface it embeds from byName, is
+// only usable as a type constraint. Embedded interfaces are only known to be
+// constraints once flattened.
+func constraint(inter *Interface, byName map[string]*Interface, visited map[string]bool) bool {
+	if inter.Constraint {
+		return true
+	}
+
+	visited[inter.Name] = true
+	for _, e := range inter.Embedded {
+		name, _ := splitTypeArgs(strings.TrimPrefix(e, "*"))
+		if embedded, ok := byName[name]; ok && !visited[name] && constraint(embedded, byName, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// selected reports whether the directives of inter, or its name matching the
+// patterns, select it to be mocked.
+func selected(inter *Interface, include, exclude []string) (bool, error) {
+	if inter.Skip {
+		return false, nil
+	}
+
+	if inter.Mock {
+		return true, nil
+	}
+
+	included, err := matchAny(include, inter.Name)
+	if err != nil {
+		return false, err
+	}
+
+	excluded, err := matchAny(exclude, inter.Name)
+	if err != nil {
+		return false, err
+	}
+
+	return (len(include) == 0 || included) && !excluded, nil
+}
+
+func matchAny(patterns []string, name string) (bool, error) {
+	for _, pattern := range patterns {
+		ok, err := matchName(pattern, name)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+
+	return false, nil
+}
+
+// matchName reports whether name matches the glob or /regular expression/
+// pattern.
+func matchName(pattern, name string) (bool, error) {
+	if len(pattern) > 1 && strings.HasPrefix(pattern, "/") && strings.HasSuffix(pattern, "/") {
+		re, err := regexp.Compile(pattern[1 : len(pattern)-1])
+		if err != nil {
+			return false, fmt.Errorf("invalid interface pattern %s: %w", pattern, err)
+		}
+
+		return re.MatchString(name), nil
+	}
+
+	ok, err := path.Match(pattern, name)
+	if err != nil {
+		return false, fmt.Errorf("invalid interface pattern %s: %w", pattern, err)
+	}
+
+	return ok, nil
+}
@@ -0,0 +1,82 @@
+package generate
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilter(t *testing.T) {
+	names := func(tempData *TemplateData) []string {
+		ret := []string{}
+		for _, inter := range tempData.Interfaces {
+			ret = append(ret, inter.Name)
+		}
+
+		return ret
+	}
+
+	newData := func() *TemplateData {
+		return &TemplateData{Interfaces: []*Interface{
+			{Name: "Reader"}, {Name: "Writer"}, {Name: "Store"}, {Name: "StoreFactory"},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+		err      bool
+	}{
+		{name: "no patterns", expected: []string{"Reader", "Writer", "Store", "StoreFactory"}},
+		{name: "exact", include: []string{"Store", "Reader"}, expected: []string{"Reader", "Store"}},
+		{name: "glob", include: []string{"Store*"}, expected: []string{"Store", "StoreFactory"}},
+		{name: "regex", include: []string{"/er$/"}, expected: []string{"Reader", "Writer"}},
+		{name: "exclude", exclude: []string{"*Factory"}, expected: []string{"Reader", "Writer", "Store"}},
+		{name: "include and exclude", include: []string{"Store*"}, exclude: []string{"/Fact/"}, expected: []string{"Store"}},
+		{name: "invalid glob", include: []string{"[a"}, err: true},
+		{name: "invalid regex", exclude: []string{"/(/"}, err: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tempData := newData()
+			err := Filter(tempData, tt.include, tt.exclude)
+			if tt.err {
+				assert.Error(t, err)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, names(tempData))
+		})
+	}
+}
+
+func TestFilterEmbedded(t *testing.T) {
+	base := &Interface{Name: "Base", Generics: []*Param{{Name: "V", Type: "any"}}}
+	reader := &Interface{Name: "Reader", Embedded: []string{"Base[string]"}}
+	store := &Interface{Name: "Store", Embedded: []string{"Reader", "io.Closer"}, Generics: []*Param{{Name: "V", Type: "any"}}}
+	other := &Interface{Name: "Other"}
+
+	tempData := &TemplateData{Interfaces: []*Interface{base, reader, store, other}}
+	assert.NoError(t, Filter(tempData, []string{"Store"}, []string{"Base"}))
+	assert.Equal(t, []*Interface{base, reader, store}, tempData.Interfaces)
+
+	// Embedded interfaces from other files of the package are kept in theirs
+	tempData = &TemplateData{Interfaces: []*Interface{base, other}}
+	assert.NoError(t, filter(tempData, []*Interface{base, reader, store, other}, []string{"Store"}, nil))
+	assert.Equal(t, []*Interface{base}, tempData.Interfaces)
+}
+
+func TestFilterConstraints(t *testing.T) {
+	number := &Interface{Name: "Number", Constraint: true}
+	sum := &Interface{Name: "Sum", Embedded: []string{"Number"}}
+	forced := &Interface{Name: "Forced", Constraint: true, Mock: true}
+	store := &Interface{Name: "Store"}
+
+	tempData := &TemplateData{Interfaces: []*Interface{number, sum, forced, store}}
+	assert.NoError(t, Filter(tempData, nil, nil))
+	assert.Equal(t, []*Interface{forced, store}, tempData.Interfaces)
+}
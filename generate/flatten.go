@@ -0,0 +1,343 @@
+package generate
+
+import (
+	"context"
+	"go/scanner"
+	"go/token"
+	"go/types"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// Flatten replaces the embedded interfaces of every interface in tempData
+// with the methods they declare, so that all expectations are set on a single
+// mock.Mock. Embedded interfaces are looked up by name in known, which should
+// hold every interface of the package. Any that cannot be found are left
+// embedded.
+func Flatten(tempData *TemplateData, known []*Interface) {
+	f := &flattener{
+		known:   map[string]*Interface{},
+		done:    map[*Interface]bool{},
+		visited: map[*Interface]bool{},
+	}
+
+	for _, inter := range known {
+		f.known[inter.Name] = inter
+	}
+
+	for _, inter := range tempData.Interfaces {
+		f.flatten(inter)
+	}
+}
+
+type flattener struct {
+	known   map[string]*Interface
+	done    map[*Interface]bool
+	visited map[*Interface]bool
+}
+
+func (f *flattener) flatten(inter *Interface) {
+	if f.done[inter] || f.visited[inter] {
+		return
+	}
+
+	f.visited[inter] = true
+	defer func() { f.done[inter] = true }()
+
+	embedded := make([]string, 0)
+	for _, e := range inter.Embedded {
+		name, args := splitTypeArgs(e)
+
+		target, ok := f.known[name]
+		if !ok || len(args) != len(target.Generics) {
+			embedded = append(embedded, e)
+			continue
+		}
+
+		f.flatten(target)
+		if target.Constraint {
+			inter.Constraint = true
+		}
+
+		mapping := map[string]string{}
+		for i, g := range target.Generics {
+			mapping[g.Name] = args[i]
+		}
+
+		for _, fun := range target.Funcs {
+			if hasFunc(inter.Funcs, fun.Name) {
+				continue
+			}
+
+			inter.Funcs = append(inter.Funcs, &Func{
+				Name:   fun.Name,
+				Params: substituteParams(fun.Params, mapping),
+				Return: substituteParams(fun.Return, mapping),
+				Pos:    fun.Pos,
+			})
+		}
+
+		for _, e := range target.Embedded {
+			if e = substitute(e, mapping); !contains(embedded, e) {
+				embedded = append(embedded, e)
+			}
+		}
+	}
+
+	inter.Embedded = nil
+	if len(embedded) > 0 {
+		inter.Embedded = embedded
+	}
+}
+
+func hasFunc(funcs []*Func, name string) bool {
+	for _, f := range funcs {
+		if f.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// splitTypeArgs splits an embedded type such as Base[K, V] into its name and
+// type arguments.
+func splitTypeArgs(embedded string) (string, []string) {
+	i := strings.Index(embedded, "[")
+	if i < 0 {
+		return embedded, nil
+	}
+
+	args := make([]string, 0)
+	depth, start := 0, i+1
+	for j := i + 1; j < len(embedded)-1; j++ {
+		switch embedded[j] {
+		case '[', '(', '{':
+			depth++
+		case ']', ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				args = append(args, strings.TrimSpace(embedded[start:j]))
+				start = j + 1
+			}
+		}
+	}
+
+	args = append(args, strings.TrimSpace(embedded[start:len(embedded)-1]))
+	return embedded[:i], args
+}
+
+func substituteParams(params []*Param, mapping map[string]string) []*Param {
+	if params == nil {
+		return nil
+	}
+
+	ret := make([]*Param, 0, len(params))
+	for _, p := range params {
+		ret = append(ret, &Param{Name: p.Name, Type: substitute(p.Type, mapping)})
+	}
+
+	return ret
+}
+
+func substituteTypes(typs []string, mapping map[string]string) []string {
+	if typs == nil {
+		return nil
+	}
+
+	ret := make([]string, 0, len(typs))
+	for _, typ := range typs {
+		ret = append(ret, substitute(typ, mapping))
+	}
+
+	return ret
+}
+
+// substitute replaces the unqualified identifiers in typ that appear in
+// mapping, e.g. map[string]T becomes map[string]int for T=int.
+func substitute(typ string, mapping map[string]string) string {
+	if len(mapping) == 0 {
+		return typ
+	}
+
+	fset := token.NewFileSet()
+	file := fset.AddFile("", fset.Base(), len(typ))
+
+	var s scanner.Scanner
+	s.Init(file, []byte(typ), nil, 0)
+
+	var b strings.Builder
+	last, prev := 0, token.ILLEGAL
+	for {
+		pos, tok, lit := s.Scan()
+		if tok == token.EOF {
+			break
+		}
+
+		offset := file.Offset(pos)
+		if replacement, ok := mapping[lit]; ok && tok == token.IDENT && prev != token.PERIOD {
+			b.WriteString(typ[last:offset])
+			b.WriteString(replacement)
+			last = offset + len(lit)
+		}
+
+		prev = tok
+	}
+
+	b.WriteString(typ[last:])
+	return b.String()
+}
+
+// InlineImported replaces interfaces embedded from other packages with the
+// methods they declare, loading those packages from dir. Their mocks are only
+// kept embedded when all is false and the package already declares one, named
+// by the naming template.
+func InlineImported(ctx context.Context, tempData *TemplateData, dir string, all bool, naming string) error {
+	names := map[string]string{}
+	paths := make([]string, 0)
+	for _, impo := range tempData.Imports {
+		name, importPath := parseImport(impo)
+		if name == "_" || name == "." {
+			continue
+		}
+
+		names[importPath] = name
+		paths = append(paths, importPath)
+	}
+
+	if !hasImportedEmbedded(tempData) || len(paths) == 0 {
+		return nil
+	}
+
+	pkgs, err := packages.Load(&packages.Config{Context: ctx, Mode: packages.NeedName | packages.NeedTypes, Dir: dir}, paths...)
+	if err != nil {
+		return err
+	}
+
+	byName := map[string]*packages.Package{}
+	for _, pkg := range pkgs {
+		if pkg.Types == nil {
+			continue
+		}
+
+		name := names[pkg.PkgPath]
+		if name == "" {
+			name = pkg.Name
+		}
+
+		byName[name] = pkg
+	}
+
+	conv := &typeConverter{imports: map[string]string{}}
+	for importPath, name := range names {
+		if name != "" {
+			conv.imports[importPath] = name
+		}
+	}
+
+	for _, inter := range tempData.Interfaces {
+		embedded := make([]string, 0)
+		for _, e := range inter.Embedded {
+			name, args := splitTypeArgs(e)
+
+			i := strings.LastIndex(name, ".")
+			if i < 0 || strings.HasPrefix(name, "*") {
+				embedded = append(embedded, e)
+				continue
+			}
+
+			pkg, ok := byName[name[:i]]
+			if !ok {
+				embedded = append(embedded, e)
+				continue
+			}
+
+			obj, ok := pkg.Types.Scope().Lookup(name[i+1:]).(*types.TypeName)
+			if !ok {
+				embedded = append(embedded, e)
+				continue
+			}
+
+			mock, err := MockName(naming, name[i+1:])
+			if err != nil {
+				return err
+			}
+
+			if !all && pkg.Types.Scope().Lookup(mock) != nil {
+				embedded = append(embedded, e)
+				continue
+			}
+
+			iface, ok := obj.Type().Underlying().(*types.Interface)
+			if !ok {
+				embedded = append(embedded, e)
+				continue
+			}
+
+			if !iface.IsMethodSet() || iface.IsComparable() {
+				inter.Constraint = true
+			}
+
+			mapping := map[string]string{}
+			if named, ok := obj.Type().(*types.Named); ok {
+				for j := 0; j < named.TypeParams().Len() && j < len(args); j++ {
+					mapping[named.TypeParams().At(j).Obj().Name()] = args[j]
+				}
+			}
+
+			for j := 0; j < iface.NumMethods(); j++ {
+				fun := conv.Func(iface.Method(j))
+				if hasFunc(inter.Funcs, fun.Name) {
+					continue
+				}
+
+				fun.Params = substituteParams(fun.Params, mapping)
+				fun.Return = substituteParams(fun.Return, mapping)
+				inter.Funcs = append(inter.Funcs, fun)
+			}
+		}
+
+		inter.Embedded = nil
+		if len(embedded) > 0 {
+			inter.Embedded = embedded
+		}
+	}
+
+	for importPath, name := range conv.imports {
+		if _, ok := names[importPath]; !ok {
+			tempData.Imports = append(tempData.Imports, formatImport(name, importPath))
+		}
+	}
+
+	return nil
+}
+
+func hasImportedEmbedded(tempData *TemplateData) bool {
+	for _, inter := range tempData.Interfaces {
+		for _, e := range inter.Embedded {
+			if name, _ := splitTypeArgs(e); strings.Contains(name, ".") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// parseImport splits an import line, as held in TemplateData.Imports, into
+// its name, which is empty when the import isn't named, and path.
+func parseImport(impo string) (name, importPath string) {
+	if i := strings.Index(impo, " "); i >= 0 {
+		name, impo = impo[:i], impo[i+1:]
+	}
+
+	importPath, err := strconv.Unquote(impo)
+	if err != nil {
+		importPath = impo
+	}
+
+	return name, importPath
+}
@@ -0,0 +1,198 @@
+package generate
+
+import (
+	"context"
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlatten(t *testing.T) {
+	testFileSrc := `package foo
+
+	import "io"
+
+	type X interface {
+		io.Reader
+		Flavour() string
+	}
+
+	type Base[K comparable, V any] interface {
+		Get(k K) (V, bool)
+		All() map[K]V
+	}
+
+	type Y interface {
+		X
+		Base[string, []int]
+		Flavour() string
+		Name() string
+	}
+	`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", testFileSrc, parser.ParseComments)
+	require.NoError(t, err)
+
+	tempData := Parse(f)
+	Flatten(tempData, tempData.Interfaces)
+
+	expected := &Interface{
+		Name: "Y",
+		Funcs: []*Func{
+			{
+				Name: "Flavour",
+				Return: []*Param{
+					{Name: "", Type: "string"},
+				},
+			},
+			{
+				Name: "Name",
+				Return: []*Param{
+					{Name: "", Type: "string"},
+				},
+			},
+			{
+				Name: "Get",
+				Params: []*Param{
+					{Name: "k", Type: "string"},
+				},
+				Return: []*Param{
+					{Name: "", Type: "[]int"},
+					{Name: "", Type: "bool"},
+				},
+			},
+			{
+				Name: "All",
+				Return: []*Param{
+					{Name: "", Type: "map[string][]int"},
+				},
+			},
+		},
+		Embedded: []string{"io.Reader"},
+	}
+	assert.Equal(t, expected, tempData.Interfaces[2])
+	assert.Equal(t, []string{"io.Reader"}, tempData.Interfaces[0].Embedded)
+	assert.Nil(t, tempData.Interfaces[1].Embedded)
+}
+
+func TestSubstitute(t *testing.T) {
+	mapping := map[string]string{"T": "name.Name", "V": "[]int"}
+
+	assert.Equal(t, "map[name.Name][]int", substitute("map[T]V", mapping))
+	assert.Equal(t, "func(name.Name) gen.T", substitute("func(T) gen.T", mapping))
+	assert.Equal(t, "Tx", substitute("Tx", mapping))
+}
+
+func TestInlineImported(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"bar/bar.go": `package bar
+
+type Thing interface {
+	Do() error
+}
+
+type MockThing struct{}
+
+type Getter[T any] interface {
+	Get() T
+}
+`,
+		"foo.go": `package foo
+
+import (
+	"fmt"
+	stdio "io"
+
+	"example.com/foo/bar"
+)
+
+type R interface {
+	stdio.ReadCloser
+	fmt.Stringer
+	bar.Thing
+	bar.Getter[[]string]
+	String() string
+}
+`,
+	})
+
+	pkg, err := ParsePackage(dir)
+	require.NoError(t, err)
+
+	tempData := Parse(pkg.Files[0])
+	require.NoError(t, InlineImported(context.Background(), tempData, dir, false, DefaultNaming))
+
+	expected := &Interface{
+		Name: "R",
+		Funcs: []*Func{
+			{
+				Name: "String",
+				Return: []*Param{
+					{Name: "", Type: "string"},
+				},
+			},
+			{
+				Name: "Close",
+				Return: []*Param{
+					{Name: "", Type: "error"},
+				},
+			},
+			{
+				Name: "Read",
+				Params: []*Param{
+					{Name: "p", Type: "[]byte"},
+				},
+				Return: []*Param{
+					{Name: "n", Type: "int"},
+					{Name: "err", Type: "error"},
+				},
+			},
+			{
+				Name: "Get",
+				Return: []*Param{
+					{Name: "", Type: "[]string"},
+				},
+			},
+		},
+		Embedded: []string{"bar.Thing"},
+	}
+	assert.Equal(t, expected, tempData.Interfaces[0])
+
+	tempData = Parse(pkg.Files[0])
+	require.NoError(t, InlineImported(context.Background(), tempData, dir, true, DefaultNaming))
+	assert.Nil(t, tempData.Interfaces[0].Embedded)
+	assert.True(t, hasFunc(tempData.Interfaces[0].Funcs, "Do"))
+}
+
+func TestInlineImportedTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+import "io"
+
+type RC interface {
+	io.ReadCloser
+	Name() string
+}
+`,
+	})
+
+	// No method uses io, it's only embedded
+	_, data, err := ParseTypes(context.Background(), dir, false)
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	assert.Equal(t, []string{"\"io\""}, data[0].Imports)
+
+	require.NoError(t, InlineImported(context.Background(), data[0], dir, false, DefaultNaming))
+	assert.Empty(t, data[0].Interfaces[0].Embedded)
+	assert.True(t, hasFunc(data[0].Interfaces[0].Funcs, "Read"))
+	assert.True(t, hasFunc(data[0].Interfaces[0].Funcs, "Close"))
+}
@@ -0,0 +1,576 @@
+package generate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Stdio is the path of an In read from stdin, or an Out written to stdout.
+const Stdio = "-"
+
+// Options selects the source to generate mocks from, exactly one of In, Pkg,
+// Patterns or Config, and how the mocks are generated.
+type Options struct {
+	// In is a single source file, or Stdio to read it from Stdin
+	In string
+	// Stdin is read for the source when In is Stdio, defaulting to os.Stdin
+	Stdin io.Reader
+	// PkgName is the package of an In without a package clause, such as a
+	// snippet read from stdin
+	PkgName string
+	// Out overrides the file the mocks of In or Pkg are written to, or Stdio
+	// to write them to stdout, as they are by default when In is Stdio
+	Out string
+	// Pkg is a package directory
+	Pkg string
+	// Patterns are package directories or patterns such as ./...
+	Patterns []string
+	// Config is a configuration file such as ridicule.yaml
+	Config string
+
+	Header      bool
+	PerFile     bool
+	Types       bool
+	Flatten     bool
+	Interfaces  []string
+	Exclude     []string
+	OutPkg      string
+	OutDir      string
+	Naming      string
+	Constructor bool
+	Expecter    bool
+	Assert      bool
+
+	// Report is called with a warning for each mocked interface that's
+	// probably not mocked as intended, if set
+	Report func(Diagnostic)
+	// Processed is called with the directory of each package matching
+	// Patterns once its mocks are generated, if set
+	Processed func(dir string)
+}
+
+// DefaultOptions returns the options the command line defaults to, without
+// any source.
+func DefaultOptions() Options {
+	return Options{
+		Flatten:     true,
+		Naming:      DefaultNaming,
+		Constructor: true,
+		Assert:      true,
+	}
+}
+
+// GeneratedFile is a generated mock file, yet to be written.
+type GeneratedFile struct {
+	// Path is where the file should be written
+	Path string
+	// Source is the file or package directory the mocks were generated from
+	Source  string
+	Content []byte
+}
+
+// Generate generates the mocks selected by opts without writing them. When
+// generating several packages a failure doesn't stop the rest, the files
+// generated being returned along with an error joining every failure.
+func Generate(ctx context.Context, opts Options) ([]GeneratedFile, error) {
+	if opts.external() && !token.IsIdentifier(opts.outPkg()) {
+		return nil, fmt.Errorf("invalid output package %s", opts.outPkg())
+	}
+
+	switch {
+	case opts.Config != "":
+		return generateConfig(ctx, &opts)
+	case len(opts.Patterns) > 0:
+		return generatePatterns(ctx, &opts)
+	case opts.Pkg != "":
+		files, err := generatePackage(ctx, &opts, opts.Pkg)
+		if err != nil {
+			return nil, fmt.Errorf("parsing package: %w", err)
+		}
+
+		return files, nil
+	case opts.In != "":
+		return generateFile(ctx, &opts)
+	}
+
+	return nil, errors.New("no source given, one of In, Pkg, Patterns or Config is required")
+}
+
+// WriteFiles writes the generated files, creating their directories. Files
+// with the path Stdio are written to stdout.
+func WriteFiles(files []GeneratedFile) error {
+	for _, f := range files {
+		if f.Path == Stdio {
+			if _, err := os.Stdout.Write(f.Content); err != nil {
+				return fmt.Errorf("writing to stdout: %w", err)
+			}
+
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+			return fmt.Errorf("creating directory: %w", err)
+		}
+
+		if err := os.WriteFile(f.Path, f.Content, 0o600); err != nil {
+			return fmt.Errorf("writing file: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Diff returns a unified diff from the file at f.Path to the generated
+// content, or an empty string when it's up to date. A file that doesn't exist
+// yet is diffed as empty, while one written to stdout can't be diffed.
+func (f GeneratedFile) Diff() (string, error) {
+	if f.Path == Stdio {
+		return "", errors.New("mocks written to stdout can't be compared with those on disk")
+	}
+
+	existing, err := os.ReadFile(f.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return "", fmt.Errorf("reading file: %w", err)
+	}
+
+	if bytes.Equal(existing, f.Content) {
+		return "", nil
+	}
+
+	diff := difflib.UnifiedDiff{
+		A:        splitLines(existing),
+		B:        splitLines(f.Content),
+		FromFile: f.Path,
+		ToFile:   f.Path + " (generated)",
+		Context:  3,
+	}
+
+	return difflib.GetUnifiedDiffString(diff)
+}
+
+// splitLines splits b into lines, keeping their line endings. Unlike
+// difflib.SplitLines it doesn't add an empty line after a trailing newline.
+func splitLines(b []byte) []string {
+	lines := strings.SplitAfter(string(b), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// generateConfig generates the mocks of every package in the configuration
+// file.
+func generateConfig(ctx context.Context, opts *Options) ([]GeneratedFile, error) {
+	config, err := LoadConfig(opts.Config)
+	if err != nil {
+		return nil, fmt.Errorf("loading config: %w", err)
+	}
+
+	files := make([]GeneratedFile, 0)
+	errs := make([]error, 0)
+	for _, pkgOpts := range config.Options(filepath.Dir(opts.Config), *opts) {
+		if len(pkgOpts.Patterns) > 0 {
+			generated, err := generatePatterns(ctx, pkgOpts)
+			files = append(files, generated...)
+			if err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		generated, err := generatePackage(ctx, pkgOpts, pkgOpts.Pkg)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing package '%s': %w", pkgOpts.Pkg, err))
+			continue
+		}
+
+		files = append(files, generated...)
+	}
+
+	return files, errors.Join(errs...)
+}
+
+// generatePatterns expands the package patterns and generates mocks for each
+// package found.
+func generatePatterns(ctx context.Context, opts *Options) ([]GeneratedFile, error) {
+	dirs, err := FindPackages(opts.Patterns)
+	if err != nil {
+		return nil, fmt.Errorf("finding packages: %w", err)
+	}
+
+	files := make([]GeneratedFile, 0)
+	errs := make([]error, 0)
+	for _, dir := range dirs {
+		if err := ctx.Err(); err != nil {
+			return files, err
+		}
+
+		generated, err := generatePackage(ctx, opts, dir)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("parsing package '%s': %w", dir, err))
+			continue
+		}
+
+		if opts.Processed != nil {
+			opts.Processed(dir)
+		}
+
+		files = append(files, generated...)
+	}
+
+	return files, errors.Join(errs...)
+}
+
+// generatePackage generates mocks for every interface in the package at dir,
+// either to a single file or to one file per source file.
+func generatePackage(ctx context.Context, opts *Options, dir string) ([]GeneratedFile, error) {
+	pkg, data, err := parseDir(ctx, opts, dir)
+	if _, ok := err.(*build.NoGoError); ok {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	merged := MergeTemplateData(data)
+	known := merged.Interfaces
+	if opts.Flatten && opts.PerFile {
+		for i := range data {
+			flattenFile(data, i)
+		}
+	} else if opts.Flatten {
+		Flatten(merged, known)
+	}
+
+	files := make([]*mockFile, 0)
+	if opts.PerFile {
+		for i, tempData := range data {
+			if err := prepare(ctx, opts, tempData, dir, known); err != nil {
+				return nil, err
+			}
+
+			files = append(files, splitByOut(tempData, opts.outDir(dir), opts.mockPath(dir, filepath.Base(pkg.Paths[i])))...)
+		}
+	} else {
+		if err := prepare(ctx, opts, merged, dir, known); err != nil {
+			return nil, err
+		}
+
+		out := opts.Out
+		if out == "" {
+			out = opts.mockPath(dir, pkg.Name)
+		}
+
+		files = splitByOut(merged, opts.outDir(dir), out)
+	}
+
+	return render(files, dir)
+}
+
+// flattenFile flattens the interfaces of data[i], one file of a package, with
+// those of every file of the package. The packages that methods copied from
+// other files refer to are imported too, named as MergeTemplateData names
+// them when merging the other files into data[i].
+func flattenFile(data []*TemplateData, i int) {
+	files := []*TemplateData{data[i]}
+	for j, tempData := range data {
+		if j != i {
+			files = append(files, tempData)
+		}
+	}
+
+	merged := MergeTemplateData(files)
+	Flatten(data[i], merged.Interfaces)
+
+	paths := map[string]bool{}
+	for _, impo := range data[i].Imports {
+		_, importPath := parseImport(impo)
+		paths[importPath] = true
+	}
+
+	// Unused imports are dropped when the mocks are rendered, though blank
+	// and dot imports would be kept
+	for _, impo := range merged.Imports {
+		name, importPath := parseImport(impo)
+		if !paths[importPath] && name != "_" && name != "." {
+			data[i].Imports = append(data[i].Imports, impo)
+		}
+	}
+}
+
+// generateFile generates mocks for every interface in the single source file
+// opts.In.
+func generateFile(ctx context.Context, opts *Options) ([]GeneratedFile, error) {
+	tempData, err := parseFile(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("parsing file: %w", err)
+	}
+
+	if opts.Flatten {
+		Flatten(tempData, tempData.Interfaces)
+	}
+
+	dir := filepath.Dir(opts.In)
+	if err := prepare(ctx, opts, tempData, dir, tempData.Interfaces); err != nil {
+		return nil, fmt.Errorf("preparing mocks: %w", err)
+	}
+
+	out := opts.Out
+	if out == "" && opts.In == Stdio {
+		out = Stdio
+	} else if out == "" {
+		out = opts.mockPath(dir, filepath.Base(opts.In))
+	}
+
+	return render(splitByOut(tempData, opts.outDir(dir), out), opts.In)
+}
+
+// render renders the mock files generated from source, merging any written to
+// the same path.
+func render(files []*mockFile, source string) ([]GeneratedFile, error) {
+	writer := NewFileWriter()
+
+	ret := make([]GeneratedFile, 0, len(files))
+	for _, f := range mergeMockFiles(files) {
+		content, err := writer.Render(f.path, f.data)
+		if err != nil {
+			return nil, err
+		}
+
+		ret = append(ret, GeneratedFile{Path: f.path, Source: source, Content: content})
+	}
+
+	return ret, nil
+}
+
+// prepare readies parsed template data for writing, inlining interfaces
+// embedded from other packages, filtering the interfaces by name and naming
+// their mocks. known holds every interface of the package.
+func prepare(ctx context.Context, opts *Options, tempData *TemplateData, dir string, known []*Interface) error {
+	if err := InlineImported(ctx, tempData, dir, opts.Flatten, opts.Naming); err != nil {
+		return err
+	}
+
+	if err := filter(tempData, known, opts.Interfaces, opts.Exclude); err != nil {
+		return err
+	}
+
+	if err := NameMocks(tempData, opts.Naming, known); err != nil {
+		return err
+	}
+
+	tempData.Header = opts.Header
+	tempData.Constructor = opts.Constructor
+	tempData.Expecter = opts.Expecter
+	tempData.Assert = opts.Assert
+
+	if opts.Report != nil {
+		for _, d := range lint(tempData, opts.external()) {
+			opts.Report(d)
+		}
+	}
+
+	return nil
+}
+
+// parseDir parses the package in dir with the front-end selected by opts,
+// returning the template data for each file of the package.
+func parseDir(ctx context.Context, opts *Options, dir string) (*Package, []*TemplateData, error) {
+	if opts.Types {
+		pkg, data, err := ParseTypes(ctx, dir, opts.external())
+		if err != nil || !opts.external() {
+			return pkg, data, err
+		}
+
+		for _, tempData := range data {
+			relocate(tempData, opts.outPkg(), pkg.Name, pkg.ImportPath)
+		}
+
+		return pkg, data, nil
+	}
+
+	pkg, err := ParsePackage(dir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if opts.external() {
+		declared := declaredNames(pkg.Files)
+		for _, f := range pkg.Files {
+			Qualify(f, pkg.Name, declared)
+		}
+
+		if pkg.ImportPath, err = importPath(ctx, dir); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	data := make([]*TemplateData, 0, len(pkg.Files))
+	for _, f := range pkg.Files {
+		tempData, err := ParseFile(pkg.Fset, f)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if opts.external() {
+			relocate(tempData, opts.outPkg(), pkg.Name, pkg.ImportPath)
+		}
+
+		data = append(data, tempData)
+	}
+
+	return pkg, data, nil
+}
+
+// parseFile parses the single source file opts.In with the front-end selected
+// by opts. The rest of its package is only parsed when it's needed to qualify
+// the file's types.
+func parseFile(ctx context.Context, opts *Options) (*TemplateData, error) {
+	dir := filepath.Dir(opts.In)
+	if opts.Types {
+		if opts.In == Stdio {
+			return nil, errors.New("type checking needs a source file rather than stdin")
+		}
+
+		pkg, data, err := parseDir(ctx, opts, dir)
+		if err != nil {
+			return nil, err
+		}
+
+		for i, path := range pkg.Paths {
+			if filepath.Base(path) == filepath.Base(opts.In) {
+				return data[i], nil
+			}
+		}
+
+		return nil, fmt.Errorf("'%s' not found in package", opts.In)
+	}
+
+	file, err := opts.readIn()
+	if err != nil {
+		return nil, err
+	}
+
+	filename := opts.In
+	if filename == Stdio {
+		filename = "<stdin>"
+	}
+
+	fset := token.NewFileSet()
+	parsedFile, err := parser.ParseFile(fset, filename, file, parser.ParseComments)
+	if err != nil {
+		return nil, parseErrors(err)
+	}
+
+	if !opts.external() {
+		return ParseFile(fset, parsedFile)
+	}
+
+	files := []*ast.File{parsedFile}
+	if pkg, err := ParsePackage(dir); err == nil {
+		files = append(files, pkg.Files...)
+	}
+
+	Qualify(parsedFile, parsedFile.Name.Name, declaredNames(files))
+
+	path, err := importPath(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	tempData, err := ParseFile(fset, parsedFile)
+	if err != nil {
+		return nil, err
+	}
+
+	relocate(tempData, opts.outPkg(), parsedFile.Name.Name, path)
+
+	return tempData, nil
+}
+
+// readIn reads the source file opts.In, from Stdin when it's Stdio. A source
+// without a package clause is put in package PkgName, if set.
+func (o *Options) readIn() ([]byte, error) {
+	var (
+		src []byte
+		err error
+	)
+
+	if o.In == Stdio {
+		stdin := o.Stdin
+		if stdin == nil {
+			stdin = os.Stdin
+		}
+
+		src, err = io.ReadAll(stdin)
+	} else {
+		src, err = os.ReadFile(o.In)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if o.PkgName != "" {
+		if _, err := parser.ParseFile(token.NewFileSet(), "", src, parser.PackageClauseOnly); err != nil {
+			// On the first line so the positions of the source are kept
+			src = append([]byte("package "+o.PkgName+";"), src...)
+		}
+	}
+
+	return src, nil
+}
+
+// external reports whether the mocks are written to a package other than the
+// one they're generated from.
+func (o *Options) external() bool {
+	return o.OutPkg != "" || o.OutDir != ""
+}
+
+// outPkg returns the name of the package the mocks are written to when
+// external.
+func (o *Options) outPkg() string {
+	if o.OutPkg != "" {
+		return o.OutPkg
+	}
+
+	return filepath.Base(filepath.Clean(o.OutDir))
+}
+
+// outDir returns the directory the mocks of the package in dir are written to.
+func (o *Options) outDir(dir string) string {
+	switch {
+	case filepath.IsAbs(o.OutDir):
+		return o.OutDir
+	case o.OutDir != "":
+		return filepath.Join(dir, o.OutDir)
+	case o.OutPkg != "" && !strings.HasSuffix(o.OutPkg, "_test"):
+		return filepath.Join(dir, o.OutPkg)
+	default:
+		return dir
+	}
+}
+
+// mockPath returns the default mock file path for the source file, or the
+// package, called name in dir, e.g. foo.go becomes foo_mock.go, or
+// foo_mock_test.go when writing to a _test package.
+func (o *Options) mockPath(dir, name string) string {
+	suffix := "_mock.go"
+	if o.external() && strings.HasSuffix(o.outPkg(), "_test") {
+		suffix = "_mock_test.go"
+	}
+
+	return filepath.Join(o.outDir(dir), strings.TrimSuffix(name, ".go")+suffix)
+}
@@ -0,0 +1,230 @@
+package generate
+
+import (
+	"context"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+type Store interface {
+	Get(key string) (string, error)
+}
+`,
+		"bar/bar.go": `package bar
+
+type Bar interface {
+	Do()
+}
+`,
+		"broken/broken.go": `package broken
+
+type Broken interface {
+`,
+	})
+
+	opts := DefaultOptions()
+	opts.In = filepath.Join(dir, "foo.go")
+	files, err := Generate(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, filepath.Join(dir, "foo_mock.go"), files[0].Path)
+	assert.Equal(t, opts.In, files[0].Source)
+	assert.Contains(t, string(files[0].Content), "func NewMockStore(")
+
+	_, err = os.Stat(files[0].Path)
+	assert.True(t, os.IsNotExist(err))
+
+	require.NoError(t, WriteFiles(files))
+	content, err := os.ReadFile(files[0].Path)
+	require.NoError(t, err)
+	assert.Equal(t, files[0].Content, content)
+
+	processed := []string{}
+	opts = DefaultOptions()
+	opts.Patterns = []string{dir + "/..."}
+	opts.Processed = func(dir string) {
+		processed = append(processed, dir)
+	}
+	files, err = Generate(context.Background(), opts)
+	assert.ErrorContains(t, err, "parsing package '"+filepath.Join(dir, "broken")+"'")
+	assert.Equal(t, []string{dir, filepath.Join(dir, "bar")}, processed)
+	require.Len(t, files, 2)
+	assert.Equal(t, filepath.Join(dir, "foo_mock.go"), files[0].Path)
+	assert.Equal(t, filepath.Join(dir, "bar", "bar_mock.go"), files[1].Path)
+
+	_, err = Generate(context.Background(), DefaultOptions())
+	assert.Error(t, err)
+
+	opts = DefaultOptions()
+	opts.Pkg, opts.OutDir = dir, "."
+	_, err = Generate(context.Background(), opts)
+	assert.ErrorContains(t, err, "invalid output package")
+}
+
+func TestGeneratePackageImportClash(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"a.go": `package foo
+
+import "text/template"
+
+type A interface {
+	Parse(t *template.Template) error
+}
+`,
+		"b.go": `package foo
+
+import "html/template"
+
+type B interface {
+	A
+	Escape(s string) template.HTML
+}
+`,
+		"c.go": `package foo
+
+import tpl "text/template"
+
+type C interface {
+	A
+	Lookup(name string) *tpl.Template
+}
+`,
+		"d.go": `package foo
+
+type D interface {
+	C
+}
+`,
+	})
+
+	for _, types := range []bool{false, true} {
+		opts := DefaultOptions()
+		opts.Pkg, opts.Types = dir, types
+		files, err := Generate(context.Background(), opts)
+		require.NoError(t, err)
+		require.Len(t, files, 1)
+
+		content := string(files[0].Content)
+		assert.Contains(t, content, "\t\"text/template\"\n")
+		assert.Contains(t, content, "\ttemplate1 \"html/template\"\n")
+		assert.Contains(t, content, "func (mock *MockA) Parse(t *template.Template) (r0 error) {")
+		assert.Contains(t, content, "func (mock *MockB) Parse(t *template.Template) (r0 error) {")
+		assert.Contains(t, content, "func (mock *MockB) Escape(s string) (r0 template1.HTML) {")
+
+		opts.PerFile = true
+		files, err = Generate(context.Background(), opts)
+		require.NoError(t, err)
+		require.Len(t, files, 4)
+		assert.Equal(t, filepath.Join(dir, "b_mock.go"), files[1].Path)
+
+		content = string(files[1].Content)
+		assert.Contains(t, content, "\t\"html/template\"\n")
+		assert.Contains(t, content, "\ttemplate1 \"text/template\"\n")
+		assert.Contains(t, content, "func (mock *MockB) Parse(t *template1.Template) (r0 error) {")
+		assert.Contains(t, content, "func (mock *MockB) Escape(s string) (r0 template.HTML) {")
+
+		content = string(files[2].Content)
+		assert.Contains(t, content, "\ttpl \"text/template\"\n")
+		assert.Contains(t, content, "func (mock *MockC) Parse(t *tpl.Template) (r0 error) {")
+
+		content = string(files[3].Content)
+		assert.Contains(t, content, "\t\"text/template\"\n")
+		assert.Contains(t, content, "func (mock *MockD) Lookup(name string) (r0 *template.Template) {")
+	}
+}
+
+func TestGenerateFilterEmbedded(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+type Base[V any] interface {
+	Get() V
+}
+
+type Store[V any] interface {
+	Base[V]
+	Put(v V)
+}
+
+type Other interface {
+	Do()
+}
+`,
+	})
+
+	opts := DefaultOptions()
+	opts.Pkg, opts.Flatten, opts.Interfaces = dir, false, []string{"Store"}
+	files, err := Generate(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	content := string(files[0].Content)
+	assert.Contains(t, content, "\tMockBase[V]\n")
+	assert.Contains(t, content, "type MockBase[V any] struct {")
+	assert.NotContains(t, content, "MockOther")
+}
+
+func TestGeneratedFileDiff(t *testing.T) {
+	dir := t.TempDir()
+	f := GeneratedFile{Path: filepath.Join(dir, "foo_mock.go"), Content: []byte("package foo\n\ntype MockFoo struct{}\n")}
+
+	diff, err := f.Diff()
+	require.NoError(t, err)
+	assert.Equal(t, "--- "+f.Path+"\n+++ "+f.Path+" (generated)\n@@ -0,0 +1,3 @@\n+package foo\n+\n+type MockFoo struct{}\n", diff)
+
+	require.NoError(t, WriteFiles([]GeneratedFile{f}))
+	diff, err = f.Diff()
+	require.NoError(t, err)
+	assert.Empty(t, diff)
+
+	f.Content = []byte("package foo\n\ntype MockBar struct{}\n")
+	diff, err = f.Diff()
+	require.NoError(t, err)
+	assert.Equal(t, "--- "+f.Path+"\n+++ "+f.Path+" (generated)\n@@ -1,3 +1,3 @@\n package foo\n \n-type MockFoo struct{}\n+type MockBar struct{}\n", diff)
+
+	_, err = GeneratedFile{Path: Stdio, Content: f.Content}.Diff()
+	assert.Error(t, err)
+}
+
+func TestGenerateStdin(t *testing.T) {
+	opts := DefaultOptions()
+	opts.In = Stdio
+	opts.PkgName = "foo"
+	opts.Stdin = strings.NewReader("type Store interface {\n\tGet(key string) (string, error)\n}\n")
+
+	files, err := Generate(context.Background(), opts)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, Stdio, files[0].Path)
+	assert.Contains(t, string(files[0].Content), "package foo\n")
+	assert.Contains(t, string(files[0].Content), "func NewMockStore(")
+
+	opts.Stdin = strings.NewReader("package bar\n\ntype Store interface {\n\tGet(key string\n}\n")
+	opts.Out = "bar_mock.go"
+	_, err = Generate(context.Background(), opts)
+
+	var parseErr *ParseError
+	require.ErrorAs(t, err, &parseErr)
+	assert.Equal(t, token.Position{Filename: "<stdin>", Offset: 51, Line: 4, Column: 16}, parseErr.Pos)
+
+	opts.Stdin = strings.NewReader("package bar\n\ntype Store interface{}\n")
+	opts.Types = true
+	_, err = Generate(context.Background(), opts)
+	assert.ErrorContains(t, err, "stdin")
+}
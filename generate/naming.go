@@ -0,0 +1,135 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultNaming is the template mocks are named with unless another is given.
+const DefaultNaming = "Mock{{.Name}}"
+
+var namingFuncs = template.FuncMap{
+	"lowerFirst": func(s string) string {
+		r, size := utf8.DecodeRuneInString(s)
+		return string(unicode.ToLower(r)) + s[size:]
+	},
+}
+
+// NameMocks names the mock of every interface in tempData by executing the
+// naming template, e.g. {{.Name}}Mock or mock{{.Name}}, or the one from its
+// //ridicule:name directive, with the name of the interface. The mocks of
+// embedded interfaces are named the same way, those of the package being
+// looked up in known so their directives are honoured.
+func NameMocks(tempData *TemplateData, naming string, known []*Interface) error {
+	names := map[string]string{}
+	for _, inter := range known {
+		name, err := mockName(inter, naming)
+		if err != nil {
+			return err
+		}
+
+		names[inter.Name] = name
+	}
+
+	for _, inter := range tempData.Interfaces {
+		name, err := mockName(inter, naming)
+		if err != nil {
+			return err
+		}
+
+		inter.MockName = name
+		inter.EmbeddedMocks = nil
+		for _, e := range inter.Embedded {
+			embedded, err := embeddedMock(e, naming, names)
+			if err != nil {
+				return err
+			}
+
+			inter.EmbeddedMocks = append(inter.EmbeddedMocks, embedded)
+		}
+	}
+
+	return nil
+}
+
+// MockName executes the naming template for the interface called name, an
+// empty template being DefaultNaming.
+func MockName(naming, name string) (string, error) {
+	if naming == "" {
+		naming = DefaultNaming
+	}
+
+	tmpl, err := template.New("name").Funcs(namingFuncs).Option("missingkey=error").Parse(naming)
+	if err != nil {
+		return "", fmt.Errorf("invalid naming template %s: %w", naming, err)
+	}
+
+	var buff bytes.Buffer
+	if err := tmpl.Execute(&buff, struct{ Name string }{Name: name}); err != nil {
+		return "", fmt.Errorf("invalid naming template %s: %w", naming, err)
+	}
+
+	if !token.IsIdentifier(buff.String()) {
+		return "", fmt.Errorf("invalid mock name %q for %s", buff.String(), name)
+	}
+
+	return buff.String(), nil
+}
+
+// mockName names the mock of inter, a name set by directive taking precedence
+// over the naming template.
+func mockName(inter *Interface, naming string) (string, error) {
+	if inter.MockName != "" {
+		return MockName(inter.MockName, inter.Name)
+	}
+
+	return MockName(naming, inter.Name)
+}
+
+// embeddedMock returns the mock to embed for an embedded interface type,
+// e.g. Base[T] becomes MockBase[T] and pkg.Thing becomes pkg.MockThing.
+func embeddedMock(embedded, naming string, names map[string]string) (string, error) {
+	pointer := strings.HasPrefix(embedded, "*")
+	embedded = strings.TrimPrefix(embedded, "*")
+
+	args := ""
+	if i := strings.Index(embedded, "["); i >= 0 {
+		embedded, args = embedded[:i], embedded[i:]
+	}
+
+	pkg := ""
+	if i := strings.LastIndex(embedded, "."); i >= 0 {
+		pkg, embedded = embedded[:i+1], embedded[i+1:]
+	}
+
+	name, ok := names[embedded]
+	if !ok || pkg != "" {
+		var err error
+		if name, err = MockName(naming, embedded); err != nil {
+			return "", err
+		}
+	}
+
+	ret := pkg + name + args
+	if pointer {
+		ret = "*" + ret
+	}
+
+	return ret, nil
+}
+
+// constructorName names the constructor of a mock, e.g. NewMockStore, or
+// newStoreMock for the unexported storeMock.
+func constructorName(mockName string) string {
+	r, size := utf8.DecodeRuneInString(mockName)
+	if unicode.IsUpper(r) {
+		return "New" + mockName
+	}
+
+	return "new" + string(unicode.ToUpper(r)) + mockName[size:]
+}
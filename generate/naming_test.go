@@ -0,0 +1,66 @@
+package generate
+
+import (
+	"go/parser"
+	"go/token"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNameMocks(t *testing.T) {
+	testFileSrc := `package foo
+
+	type Base[T any] interface {
+		Get() T
+	}
+
+	//ridicule:name=Fake{{.Name}}
+	type Other interface {
+		Do()
+	}
+
+	type Y interface {
+		Base[string]
+		*Other
+		bar.Thing
+	}
+	`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", testFileSrc, parser.ParseComments)
+	require.NoError(t, err)
+
+	tempData := Parse(f)
+	require.NoError(t, NameMocks(tempData, "{{lowerFirst .Name}}Mock", tempData.Interfaces))
+
+	assert.Equal(t, "baseMock", tempData.Interfaces[0].MockName)
+	assert.Equal(t, "FakeOther", tempData.Interfaces[1].MockName)
+	assert.Equal(t, "yMock", tempData.Interfaces[2].MockName)
+	assert.Equal(t, []string{"baseMock[string]", "*FakeOther", "bar.thingMock"}, tempData.Interfaces[2].EmbeddedMocks)
+
+	// Naming is idempotent
+	require.NoError(t, NameMocks(tempData, "{{lowerFirst .Name}}Mock", tempData.Interfaces))
+	assert.Equal(t, "FakeOther", tempData.Interfaces[1].MockName)
+
+	ret, err := writeMock(tempData, NewFileWriter(), "foo_mock.go")
+	require.NoError(t, err)
+	assert.Contains(t, string(ret), "type yMock struct {\n\tmock.Mock\n\tbaseMock[string]\n\t*FakeOther\n\tbar.thingMock\n}")
+}
+
+func TestMockName(t *testing.T) {
+	name, err := MockName("", "Store")
+	require.NoError(t, err)
+	assert.Equal(t, "MockStore", name)
+
+	name, err = MockName("{{.Name}}Mock", "Store")
+	require.NoError(t, err)
+	assert.Equal(t, "StoreMock", name)
+
+	_, err = MockName("{{.Nme}}", "Store")
+	assert.Error(t, err)
+
+	_, err = MockName("Mock-{{.Name}}", "Store")
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package generate
+
+import (
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Package holds the parsed non-test source files of a single package.
+type Package struct {
+	Name       string
+	Dir        string
+	ImportPath string
+	Paths      []string
+	Files      []*ast.File
+	Fset       *token.FileSet
+}
+
+// ParsePackage parses every non-test, non-mock go file in dir that matches the
+// current build context.
+func ParsePackage(dir string) (*Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	names := append(bp.GoFiles, bp.CgoFiles...)
+	sort.Strings(names)
+
+	fset := token.NewFileSet()
+	pkg := &Package{Name: bp.Name, Dir: dir, Fset: fset}
+	for _, name := range names {
+		if strings.HasSuffix(name, "_mock.go") {
+			continue
+		}
+
+		path := filepath.Join(dir, name)
+		f, err := parser.ParseFile(fset, path, nil, parser.ParseComments)
+		if err != nil {
+			return nil, parseErrors(err)
+		}
+
+		pkg.Paths = append(pkg.Paths, path)
+		pkg.Files = append(pkg.Files, f)
+	}
+
+	return pkg, nil
+}
+
+// FindPackages expands the given patterns into package directories. A pattern
+// ending in "/..." matches the directory and every directory below it,
+// skipping vendor, testdata and hidden directories as well as nested modules.
+// Any other pattern is treated as a single package directory.
+func FindPackages(patterns []string) ([]string, error) {
+	dirs := make([]string, 0)
+	for _, pattern := range patterns {
+		if !isPattern(pattern) {
+			if !contains(dirs, pattern) {
+				dirs = append(dirs, pattern)
+			}
+			continue
+		}
+
+		root := strings.TrimSuffix(strings.TrimSuffix(pattern, "..."), "/")
+		if root == "" {
+			root = "."
+		}
+
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !d.IsDir() {
+				return nil
+			}
+
+			if path != root {
+				name := d.Name()
+				if name == "vendor" || name == "testdata" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_") {
+					return filepath.SkipDir
+				}
+
+				if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+					return filepath.SkipDir
+				}
+			}
+
+			if hasGoFiles(path) && !contains(dirs, path) {
+				dirs = append(dirs, path)
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return dirs, nil
+}
+
+// hasGoFiles reports whether dir contains any non-test, non-mock go files.
+func hasGoFiles(dir string) bool {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return false
+	}
+
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, ".go") {
+			continue
+		}
+
+		if strings.HasSuffix(name, "_test.go") || strings.HasSuffix(name, "_mock.go") {
+			continue
+		}
+
+		return true
+	}
+
+	return false
+}
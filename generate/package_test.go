@@ -0,0 +1,102 @@
+package generate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+}
+
+func TestParsePackage(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"a.go": `package foo
+
+import "context"
+
+type A interface {
+	Do(ctx context.Context) error
+}
+`,
+		"b.go": `package foo
+
+import "context"
+
+type B interface {
+	Undo(ctx context.Context) error
+}
+`,
+		"a_test.go": `package foo
+
+type T interface {
+	Test()
+}
+`,
+		"foo_mock.go": `package foo
+
+type M interface {
+	Mocked()
+}
+`,
+	})
+
+	pkg, err := ParsePackage(dir)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", pkg.Name)
+	assert.Equal(t, []string{filepath.Join(dir, "a.go"), filepath.Join(dir, "b.go")}, pkg.Paths)
+
+	tempData := ParseFiles(pkg.Files)
+	assert.Equal(t, "foo", tempData.Package)
+	assert.Equal(t, []string{"\"context\""}, tempData.Imports)
+
+	names := []string{}
+	for _, inter := range tempData.Interfaces {
+		names = append(names, inter.Name)
+	}
+	assert.Equal(t, []string{"A", "B"}, names)
+}
+
+func TestFindPackages(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":                 "module example.com/foo\n",
+		"foo.go":                 "package foo\n",
+		"bar/bar.go":             "package bar\n",
+		"bar/baz/baz.go":         "package baz\n",
+		"onlytests/x_test.go":    "package onlytests\n",
+		"onlymocks/x_mock.go":    "package onlymocks\n",
+		"vendor/v/v.go":          "package v\n",
+		"testdata/td/td.go":      "package td\n",
+		".hidden/h.go":           "package h\n",
+		"nested/go.mod":          "module example.com/nested\n",
+		"nested/nested.go":       "package nested\n",
+		"nested/inner/inner.go":  "package inner\n",
+		"empty/README.md":        "nothing here\n",
+		"bar/baz/extra/extra.go": "package extra\n",
+	})
+
+	dirs, err := FindPackages([]string{dir + "/..."})
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		dir,
+		filepath.Join(dir, "bar"),
+		filepath.Join(dir, "bar", "baz"),
+		filepath.Join(dir, "bar", "baz", "extra"),
+	}, dirs)
+
+	dirs, err = FindPackages([]string{filepath.Join(dir, "bar"), filepath.Join(dir, "bar")})
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(dir, "bar")}, dirs)
+}
@@ -0,0 +1,712 @@
+package generate
+
+import (
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"path"
+	"strings"
+)
+
+// TemplateData holds the interfaces to mock in a single generated file.
+type TemplateData struct {
+	Package     string
+	Interfaces  []*Interface
+	Imports     []string
+	Header      bool
+	Constructor bool
+	Expecter    bool
+	Assert      bool
+	// SourcePackage is the name the source package is imported as when the
+	// mocks are written to another package.
+	SourcePackage string
+}
+
+// Interface is an interface to mock.
+type Interface struct {
+	Name          string
+	MockName      string
+	Funcs         []*Func
+	Embedded      []string
+	EmbeddedMocks []string
+	Generics      []*Param
+	Mock          bool
+	Skip          bool
+	Out           string
+	Constraint    bool
+	// Pos is where the interface is declared, if known.
+	Pos token.Position
+}
+
+// Func is a method of an interface.
+type Func struct {
+	Name   string
+	Params []*Param
+	Return []*Param
+	// Pos is where the method is declared, if known.
+	Pos token.Position
+}
+
+// Param is a parameter, result or type parameter of a method or interface.
+type Param struct {
+	Name string
+	Type string
+}
+
+// Parse builds the template data for the interfaces declared at the top level
+// of f from its syntax tree, ignoring any types it doesn't support. Use
+// ParseFile to find out about those.
+func Parse(f *ast.File) *TemplateData {
+	tempData, _ := ParseFile(nil, f)
+	return tempData
+}
+
+// ParseFile builds the template data for the interfaces declared at the top
+// level of f from its syntax tree, returning an UnsupportedTypeError for each
+// type it can't render, positioned using fset.
+func ParseFile(fset *token.FileSet, f *ast.File) (*TemplateData, error) {
+	fp := &fileParser{fset: fset}
+	tempData := &TemplateData{}
+
+	tempData.Package = f.Name.Name
+
+	tempData.Interfaces = make([]*Interface, 0)
+	// Only top level declarations, types declared inside functions can't be
+	// referenced by the mocks
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			switch x := spec.(type) {
+			// find variable declarations
+			case *ast.TypeSpec:
+				switch x.Type.(type) {
+				// and are interfaces
+				case *ast.InterfaceType:
+					inter := &Interface{Name: x.Name.Name, Pos: fp.position(x.Name.Pos())}
+					fp.inter, fp.fun = inter.Name, ""
+					applyDirectives(inter, typeSpecDocs(gen, x)...)
+					if x.TypeParams != nil {
+						// handle generics
+						inter.Generics = []*Param{}
+						for _, tp := range x.TypeParams.List {
+							inter.Generics = append(inter.Generics, fp.processExpr(tp.Type, getNames(tp))...)
+						}
+					}
+
+					i := x.Type.(*ast.InterfaceType)
+
+					for _, method := range i.Methods.List {
+						fun := &Func{Pos: fp.position(method.Pos())}
+
+						if len(method.Names) > 0 {
+							fun.Name = method.Names[0].Name
+							fp.fun = fun.Name
+						} else {
+							fp.fun = ""
+
+							// Assume its an embedded interface, unless it's a
+							// type element only allowed in constraints
+							if isTypeElement(method.Type) {
+								inter.Constraint = true
+							} else if embedded := fp.processEmbedded(method.Type); embedded != "" {
+								inter.Embedded = append(inter.Embedded, embedded)
+							}
+
+							continue
+						}
+
+						if funcType, ok := method.Type.(*ast.FuncType); ok {
+							for _, p := range funcType.Params.List {
+								params := fp.getParams(p)
+								fun.Params = append(fun.Params, params...)
+							}
+
+							if funcType.Results != nil {
+								for _, r := range funcType.Results.List {
+									ret := fp.getParams(r)
+									fun.Return = append(fun.Return, ret...)
+								}
+							}
+						}
+						inter.Funcs = append(inter.Funcs, fun)
+					}
+
+					tempData.Interfaces = append(tempData.Interfaces, inter)
+				}
+			}
+		}
+	}
+
+	tempData.Imports = make([]string, 0)
+	for _, impo := range f.Imports {
+		if impo.Name != nil {
+			tempData.Imports = append(tempData.Imports, fmt.Sprintf("%s %s", impo.Name.Name, impo.Path.Value))
+		} else {
+			tempData.Imports = append(tempData.Imports, impo.Path.Value)
+		}
+	}
+
+	return tempData, errors.Join(fp.errs...)
+}
+
+// ParseFiles parses every file of a single package and merges the results.
+func ParseFiles(files []*ast.File) *TemplateData {
+	data := make([]*TemplateData, 0, len(files))
+	for _, f := range files {
+		data = append(data, Parse(f))
+	}
+
+	return MergeTemplateData(data)
+}
+
+// MergeTemplateData merges the template data of several files of a single
+// package, de-duplicating the imports. A file importing a package under a name
+// already taken by another path has it numbered, e.g. template1, its
+// interfaces being copied with their types renamed to match.
+func MergeTemplateData(data []*TemplateData) *TemplateData {
+	tempData := &TemplateData{
+		Interfaces: make([]*Interface, 0),
+		Imports:    make([]string, 0),
+	}
+
+	// The name each path is imported as, and the path each name imports
+	names := map[string]string{}
+	paths := map[string]string{}
+	for _, fileData := range data {
+		tempData.Package = fileData.Package
+		tempData.SourcePackage = fileData.SourcePackage
+
+		mapping := map[string]string{}
+		for _, impo := range fileData.Imports {
+			name, importPath := parseImport(impo)
+			if name == "_" || name == "." {
+				if !contains(tempData.Imports, impo) {
+					tempData.Imports = append(tempData.Imports, impo)
+				}
+				continue
+			}
+
+			if name == "" {
+				name = path.Base(importPath)
+			}
+
+			merged, ok := names[importPath]
+			if !ok {
+				merged = name
+				for i := 1; paths[merged] != ""; i++ {
+					merged = fmt.Sprintf("%s%d", name, i)
+				}
+
+				names[importPath], paths[merged] = merged, importPath
+				tempData.Imports = append(tempData.Imports, formatImport(merged, importPath))
+			}
+
+			if merged != name {
+				mapping[name] = merged
+			}
+		}
+
+		for _, inter := range fileData.Interfaces {
+			if len(mapping) > 0 {
+				inter = renameQualifiers(inter, mapping)
+			}
+
+			tempData.Interfaces = append(tempData.Interfaces, inter)
+		}
+	}
+
+	return tempData
+}
+
+// renameQualifiers returns a copy of inter with the packages its types refer to
+// renamed by mapping, e.g. template.HTML becomes template1.HTML.
+func renameQualifiers(inter *Interface, mapping map[string]string) *Interface {
+	cp := *inter
+	cp.Generics = substituteParams(inter.Generics, mapping)
+	cp.Funcs = make([]*Func, 0, len(inter.Funcs))
+	for _, fun := range inter.Funcs {
+		cp.Funcs = append(cp.Funcs, &Func{
+			Name:   fun.Name,
+			Params: substituteParams(fun.Params, mapping),
+			Return: substituteParams(fun.Return, mapping),
+			Pos:    fun.Pos,
+		})
+	}
+
+	cp.Embedded = substituteTypes(inter.Embedded, mapping)
+	cp.EmbeddedMocks = substituteTypes(inter.EmbeddedMocks, mapping)
+
+	return &cp
+}
+
+// fileParser renders the types of a single file, collecting any it doesn't
+// support.
+type fileParser struct {
+	fset *token.FileSet
+	errs []error
+	// inter and fun are the interface and method being parsed
+	inter, fun string
+}
+
+// position returns the position of pos, or the zero position without a file
+// set.
+func (fp *fileParser) position(pos token.Pos) token.Position {
+	if fp.fset == nil {
+		return token.Position{}
+	}
+
+	return fp.fset.Position(pos)
+}
+
+func (fp *fileParser) unsupported(e ast.Expr) {
+	fp.errs = append(fp.errs, &UnsupportedTypeError{
+		Pos:       fp.position(e.Pos()),
+		Interface: fp.inter,
+		Method:    fp.fun,
+		Type:      fmt.Sprintf("%T", e),
+	})
+}
+
+// processEmbedded returns the type of an interface embedded in another, or an
+// empty string if it is not supported.
+func (fp *fileParser) processEmbedded(e ast.Expr) string {
+	switch e.(type) {
+	case *ast.Ident, *ast.SelectorExpr, *ast.StarExpr, *ast.IndexExpr, *ast.IndexListExpr:
+		return fp.processExpr(e, []string{})[0].Type
+	}
+
+	return ""
+}
+
+// isTypeElement reports whether an expression embedded in an interface is a
+// type element, such as ~int, int | string or comparable, making the interface
+// usable only as a type constraint.
+func isTypeElement(e ast.Expr) bool {
+	switch t := e.(type) {
+	case *ast.UnaryExpr, *ast.BinaryExpr:
+		return true
+	case *ast.Ident:
+		_, ok := types.Universe.Lookup(t.Name).(*types.TypeName)
+		return ok && t.Name != "error" && t.Name != "any"
+	}
+
+	return false
+}
+
+func (fp *fileParser) processExpr(e ast.Expr, names []string) []*Param {
+	params := make([]*Param, 0)
+	switch t := e.(type) {
+	case *ast.SelectorExpr:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processSelectorExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processSelectorExpr(t)})
+		}
+	case *ast.StarExpr:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processStarExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processStarExpr(t)})
+		}
+	case *ast.Ident:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: t.Name,
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: t.Name})
+		}
+	case *ast.MapType:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processMapExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processMapExpr(t)})
+		}
+	case *ast.InterfaceType:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processInterfaceExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processInterfaceExpr(t)})
+		}
+	case *ast.StructType:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processStructExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processStructExpr(t)})
+		}
+	case *ast.ArrayType:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processArrayExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processArrayExpr(t)})
+		}
+	case *ast.Ellipsis:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processEllipsisExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processEllipsisExpr(t)})
+		}
+	case *ast.FuncType:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processFuncExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processFuncExpr(t)})
+		}
+	case *ast.IndexListExpr:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processIndexListExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{
+				Type: fp.processIndexListExpr(t),
+			})
+		}
+	case *ast.ChanType:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processChanExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processChanExpr(t)})
+		}
+	case *ast.IndexExpr:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processIndexExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{
+				Type: fp.processIndexExpr(t),
+			})
+		}
+	case *ast.BinaryExpr:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processBinaryExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processBinaryExpr(t)})
+		}
+	case *ast.UnaryExpr:
+		for _, n := range names {
+			params = append(params, &Param{
+				Name: n,
+				Type: fp.processUnaryExpr(t),
+			})
+		}
+
+		if len(names) == 0 {
+			params = append(params, &Param{Type: fp.processUnaryExpr(t)})
+		}
+	case *ast.ParenExpr:
+		params = append(params, fp.processExpr(t.X, names)...)
+	default:
+		fp.unsupported(e)
+	}
+
+	return params
+}
+
+func (fp *fileParser) getParams(p *ast.Field) []*Param {
+	names := getNames(p)
+	params := fp.processExpr(p.Type, names)
+
+	return params
+}
+
+func contains(arr []string, s string) bool {
+	for _, a := range arr {
+		if a == s {
+			return true
+		}
+	}
+
+	return false
+}
+
+func getNames(p *ast.Field) (ret []string) {
+	for _, n := range p.Names {
+		ret = append(ret, n.Name)
+	}
+
+	return ret
+}
+
+func (fp *fileParser) processSelectorExpr(t *ast.SelectorExpr) (ret string) {
+	if ident, ok := t.X.(*ast.Ident); ok {
+		ret += ident.Name
+	}
+
+	return ret + "." + t.Sel.Name // context.Context
+}
+
+func (fp *fileParser) processStarExpr(t *ast.StarExpr) (ret string) {
+	retArr := make([]string, 0)
+	for _, p := range fp.processExpr(t.X, []string{}) {
+		x := "*"
+		x += p.Type
+		retArr = append(retArr, x)
+	}
+
+	return strings.Join(retArr, ", ") // []string
+}
+
+func (fp *fileParser) processMapExpr(t *ast.MapType) (ret string) {
+	ret += "map["
+	for _, p := range fp.processExpr(t.Key, []string{}) {
+		ret += p.Type
+	}
+
+	ret += "]"
+	for _, p := range fp.processExpr(t.Value, []string{}) {
+		ret += p.Type
+	}
+
+	return ret // map[int]string
+}
+
+func (fp *fileParser) processArrayExpr(t *ast.ArrayType) (ret string) {
+	length := ""
+	if t.Len != nil {
+		length = types.ExprString(t.Len)
+	}
+
+	retArr := make([]string, 0)
+	for _, p := range fp.processExpr(t.Elt, []string{}) {
+		x := "[" + length + "]"
+		x += p.Type
+		retArr = append(retArr, x)
+	}
+
+	return strings.Join(retArr, ", ") // []string, [32]byte
+}
+
+func (fp *fileParser) processEllipsisExpr(t *ast.Ellipsis) (ret string) {
+	retArr := make([]string, 0)
+	for _, p := range fp.processExpr(t.Elt, []string{}) {
+		str := "..."
+		if p.Name != "" {
+			str += p.Name + " "
+		}
+
+		str += p.Type
+		retArr = append(retArr, str)
+	}
+
+	return strings.Join(retArr, ", ") // ...Message
+}
+
+func (fp *fileParser) processFuncExpr(t *ast.FuncType) (ret string) {
+	params := make([]string, 0)
+	if t.Params != nil {
+		for _, p := range t.Params.List {
+			for _, x := range fp.getParams(p) {
+				params = append(params, x.Type)
+			}
+		}
+	}
+
+	ret = "func(" + strings.Join(params, ", ") + ")"
+	if t.Results == nil || len(t.Results.List) == 0 {
+		return ret // func(int)
+	}
+
+	named := false
+	results := make([]string, 0)
+	for _, p := range t.Results.List {
+		for _, x := range fp.getParams(p) {
+			if x.Name != "" {
+				named = true
+				results = append(results, x.Name+" "+x.Type)
+			} else {
+				results = append(results, x.Type)
+			}
+		}
+	}
+
+	if named || len(results) > 1 {
+		return ret + " (" + strings.Join(results, ", ") + ")" // func(string) (ok bool)
+	}
+
+	return ret + " " + strings.Join(results, ", ") // func(string) bool
+}
+
+func (fp *fileParser) processInterfaceExpr(t *ast.InterfaceType) (ret string) {
+	if t.Methods == nil || len(t.Methods.List) == 0 {
+		return "interface{}"
+	}
+
+	retArr := make([]string, 0)
+	for _, m := range t.Methods.List {
+		if len(m.Names) == 0 {
+			// Embedded interface
+			for _, p := range fp.processExpr(m.Type, []string{}) {
+				retArr = append(retArr, p.Type)
+			}
+			continue
+		}
+
+		if funcType, ok := m.Type.(*ast.FuncType); ok {
+			retArr = append(retArr, m.Names[0].Name+strings.TrimPrefix(fp.processFuncExpr(funcType), "func"))
+		}
+	}
+
+	return "interface{ " + strings.Join(retArr, "; ") + " }" // interface{ Close() error }
+}
+
+func (fp *fileParser) processStructExpr(t *ast.StructType) (ret string) {
+	if t.Fields == nil || len(t.Fields.List) == 0 {
+		return "struct{}"
+	}
+
+	retArr := make([]string, 0)
+	for _, f := range t.Fields.List {
+		fieldTypes := make([]string, 0)
+		for _, p := range fp.processExpr(f.Type, []string{}) {
+			fieldTypes = append(fieldTypes, p.Type)
+		}
+
+		field := strings.Join(fieldTypes, ", ")
+		if len(f.Names) > 0 {
+			field = strings.Join(getNames(f), ", ") + " " + field
+		}
+
+		if f.Tag != nil {
+			field += " " + f.Tag.Value
+		}
+
+		retArr = append(retArr, field)
+	}
+
+	return "struct{ " + strings.Join(retArr, "; ") + " }" // struct{ Hits, Misses int }
+}
+
+func (fp *fileParser) processBinaryExpr(t *ast.BinaryExpr) (ret string) {
+	retArr := make([]string, 0)
+	for _, e := range []ast.Expr{t.X, t.Y} {
+		for _, p := range fp.processExpr(e, []string{}) {
+			retArr = append(retArr, p.Type)
+		}
+	}
+
+	return strings.Join(retArr, " "+t.Op.String()+" ") // ~int | ~string
+}
+
+func (fp *fileParser) processUnaryExpr(t *ast.UnaryExpr) (ret string) {
+	retArr := make([]string, 0)
+	for _, p := range fp.processExpr(t.X, []string{}) {
+		retArr = append(retArr, t.Op.String()+p.Type)
+	}
+
+	return strings.Join(retArr, ", ") // ~int
+}
+
+func (fp *fileParser) processIndexExpr(t *ast.IndexExpr) (ret string) {
+	retArr := make([]string, 0)
+	for _, p := range fp.processExpr(t.Index, []string{}) {
+		retArr = append(retArr, p.Type)
+	}
+
+	return fp.processExpr(t.X, []string{})[0].Type + "[" + strings.Join(retArr, ", ") + "]" // gen.Option[name.Name]
+}
+
+func (fp *fileParser) processIndexListExpr(t *ast.IndexListExpr) (ret string) {
+	retArr := make([]string, 0)
+	for _, i := range t.Indices {
+		for _, p := range fp.processExpr(i, []string{}) {
+			retArr = append(retArr, p.Type)
+		}
+	}
+
+	return fp.processExpr(t.X, []string{})[0].Type + "[" + strings.Join(retArr, ", ") + "]" // gen.Generic[name.Name, string]
+}
+
+func (fp *fileParser) processChanExpr(t *ast.ChanType) (ret string) {
+	switch t.Dir {
+	case ast.SEND:
+		ret = "chan<- "
+	case ast.RECV:
+		ret = "<-chan "
+	default:
+		ret = "chan "
+	}
+
+	value := t.Value
+	for paren, ok := value.(*ast.ParenExpr); ok; paren, ok = value.(*ast.ParenExpr) {
+		value = paren.X
+	}
+
+	retArr := make([]string, 0)
+	for _, p := range fp.processExpr(value, []string{}) {
+		if elem, ok := value.(*ast.ChanType); ok && t.Dir == ast.SEND|ast.RECV && elem.Dir == ast.RECV {
+			// chan <-chan int would be read as chan<- chan int
+			retArr = append(retArr, ret+"("+p.Type+")")
+		} else {
+			retArr = append(retArr, ret+p.Type)
+		}
+	}
+
+	return strings.Join(retArr, ", ") // <-chan string
+}
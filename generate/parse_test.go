@@ -0,0 +1,1102 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	testFileSrc := `package foo
+
+	import (
+		"crypto/sha256"
+
+		name "github.com/scottkgregory/name"
+		"github.com/scottkgregory/gen"
+	)
+
+	type X interface {
+		Flavour() string
+	}
+
+	func local() {
+		type Local interface {
+			Ignored()
+		}
+	}
+
+	type Base[T any] interface {
+		Get() T
+	}
+
+	type Store[K, V any, N ~int | ~string | float64, S ~[]K, C interface{ comparable; String() string }] interface {
+		Put(k K, v V) (N, S, C)
+	}
+
+	type Y[T any] interface {
+		X
+		Base[T]
+		gen.Pair[T, string]
+		Name() name.Name
+		YYY(x int, y string, b bool) (int, error)
+		ZZZ(x *int) (int, error)
+		AAAA(x *int) (length *int, err error)
+		BBBB(x map[string]*int) (length *int, err error)
+		CCCC(x []name.Name) (length *int, err error)
+		DDDD(x ...name.Name) (length *int, err error)
+		EEEE(x func(y int, z string) error) (length *int, err error)
+		FFFF(y T) error
+		GGGG(y T) map[string]T
+		HHHH(y T) gen.Generic[name.Name, string]
+		IIII(x chan<- T, y <-chan *int, z chan (<-chan int)) chan name.Name
+		JJJJ(x [sha256.Size]byte, y [2 * size][]T) [32]byte
+		KKKK(x interface{ Close() error }, y interface{}) struct{ Hits, Misses int ` + "`json:\"hits\"`" + `; Name string }
+		LLLL(x struct{}) interface{ name.Namer; Len() int }
+		MMMM(x gen.Option[T]) Base[name.Name]
+		NNNN(a func(int), b func(x ...string) (err error), c func() (a, b int), d func(func(int) bool) func() error) interface{ Close() }
+	}
+	`
+
+	expectedData := &TemplateData{
+		Package: "foo",
+		Interfaces: []*Interface{
+			{
+				Name:     "X",
+				MockName: "",
+				Funcs: []*Func{
+					{
+						Name: "Flavour",
+						Return: []*Param{
+							{Name: "", Type: "string"},
+						},
+					},
+				},
+			},
+			{
+				Name:     "Base",
+				MockName: "",
+				Funcs: []*Func{
+					{
+						Name: "Get",
+						Return: []*Param{
+							{Name: "", Type: "T"},
+						},
+					},
+				},
+				Generics: []*Param{
+					{Name: "T", Type: "any"},
+				},
+			},
+			{
+				Name:     "Store",
+				MockName: "",
+				Funcs: []*Func{
+					{
+						Name: "Put",
+						Params: []*Param{
+							{Name: "k", Type: "K"},
+							{Name: "v", Type: "V"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "N"},
+							{Name: "", Type: "S"},
+							{Name: "", Type: "C"},
+						},
+					},
+				},
+				Generics: []*Param{
+					{Name: "K", Type: "any"},
+					{Name: "V", Type: "any"},
+					{Name: "N", Type: "~int | ~string | float64"},
+					{Name: "S", Type: "~[]K"},
+					{Name: "C", Type: "interface{ comparable; String() string }"},
+				},
+			},
+			{
+				Name:     "Y",
+				MockName: "",
+				Funcs: []*Func{
+					{
+						Name: "Name",
+						Return: []*Param{
+							{Name: "", Type: "name.Name"},
+						},
+					},
+					{
+						Name: "YYY",
+						Params: []*Param{
+							{Name: "x", Type: "int"},
+							{Name: "y", Type: "string"},
+							{Name: "b", Type: "bool"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "int"},
+							{Name: "", Type: "error"},
+						},
+					},
+					{
+						Name: "ZZZ",
+						Params: []*Param{
+							{Name: "x", Type: "*int"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "int"},
+							{Name: "", Type: "error"},
+						},
+					},
+					{
+						Name: "AAAA",
+						Params: []*Param{
+							{Name: "x", Type: "*int"},
+						},
+						Return: []*Param{
+							{Name: "length", Type: "*int"},
+							{Name: "err", Type: "error"},
+						},
+					},
+					{
+						Name: "BBBB",
+						Params: []*Param{
+							{Name: "x", Type: "map[string]*int"},
+						},
+						Return: []*Param{
+							{Name: "length", Type: "*int"},
+							{Name: "err", Type: "error"},
+						},
+					},
+					{
+						Name: "CCCC",
+						Params: []*Param{
+							{Name: "x", Type: "[]name.Name"},
+						},
+						Return: []*Param{
+							{Name: "length", Type: "*int"},
+							{Name: "err", Type: "error"},
+						},
+					},
+					{
+						Name: "DDDD",
+						Params: []*Param{
+							{Name: "x", Type: "...name.Name"},
+						},
+						Return: []*Param{
+							{Name: "length", Type: "*int"},
+							{Name: "err", Type: "error"},
+						},
+					},
+					{
+						Name: "EEEE",
+						Params: []*Param{
+							{Name: "x", Type: "func(int, string) error"},
+						},
+						Return: []*Param{
+							{Name: "length", Type: "*int"},
+							{Name: "err", Type: "error"},
+						},
+					},
+					{
+						Name: "FFFF",
+						Params: []*Param{
+							{Name: "y", Type: "T"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "error"},
+						},
+					},
+					{
+						Name: "GGGG",
+						Params: []*Param{
+							{Name: "y", Type: "T"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "map[string]T"},
+						},
+					},
+					{
+						Name: "HHHH",
+						Params: []*Param{
+							{Name: "y", Type: "T"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "gen.Generic[name.Name, string]"},
+						},
+					},
+					{
+						Name: "IIII",
+						Params: []*Param{
+							{Name: "x", Type: "chan<- T"},
+							{Name: "y", Type: "<-chan *int"},
+							{Name: "z", Type: "chan (<-chan int)"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "chan name.Name"},
+						},
+					},
+					{
+						Name: "JJJJ",
+						Params: []*Param{
+							{Name: "x", Type: "[sha256.Size]byte"},
+							{Name: "y", Type: "[2 * size][]T"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "[32]byte"},
+						},
+					},
+					{
+						Name: "KKKK",
+						Params: []*Param{
+							{Name: "x", Type: "interface{ Close() error }"},
+							{Name: "y", Type: "interface{}"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "struct{ Hits, Misses int `json:\"hits\"`; Name string }"},
+						},
+					},
+					{
+						Name: "LLLL",
+						Params: []*Param{
+							{Name: "x", Type: "struct{}"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "interface{ name.Namer; Len() int }"},
+						},
+					},
+					{
+						Name: "MMMM",
+						Params: []*Param{
+							{Name: "x", Type: "gen.Option[T]"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "Base[name.Name]"},
+						},
+					},
+					{
+						Name: "NNNN",
+						Params: []*Param{
+							{Name: "a", Type: "func(int)"},
+							{Name: "b", Type: "func(...string) (err error)"},
+							{Name: "c", Type: "func() (a int, b int)"},
+							{Name: "d", Type: "func(func(int) bool) func() error"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "interface{ Close() }"},
+						},
+					},
+				},
+				Generics: []*Param{
+					{Name: "T", Type: "any"},
+				},
+				Embedded: []string{"X", "Base[T]", "gen.Pair[T, string]"},
+			},
+		},
+		Imports: []string{"\"crypto/sha256\"", "name \"github.com/scottkgregory/name\"", "\"github.com/scottkgregory/gen\""},
+		Header:  false,
+	}
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", testFileSrc, parser.ParseComments)
+	if err != nil {
+		fmt.Printf("error: parsing file: %s\n", err)
+		return
+	}
+
+	templateData := Parse(f)
+	assert.Equal(t, expectedData, templateData)
+
+	templateData.Header = true
+	expectedFile := `// Code generated by 'ridicule' DO NOT EDIT.
+//
+// ######   #####     ######   #####  #######    ####### ######  ####### #######
+// ####### #######    ####### ####### #######    ####### ####### ####### #######
+// ### ### ### ###    ### ### ### ###   ###      ###     ### ###   ###     ###
+// ### ### ### ###    ### ### ### ###   ###      ####### ### ###   ###     ###
+// ### ### ### ###    ### ### ### ###   ###      ###     ### ###   ###     ###
+// ####### #######    ### ### #######   ###      ####### ####### #######   ###
+// ######   #####     ### ###  #####    ###      ####### ######  #######   ###
+//
+// *** DO NOT EDIT *** This file was generated by 'ridicule' *** DO NOT EDIT ***
+
+package foo
+
+import (
+	"crypto/sha256"
+
+	"github.com/scottkgregory/gen"
+	name "github.com/scottkgregory/name"
+	"github.com/stretchr/testify/mock"
+)
+
+// MockX mocks the X interface
+type MockX struct {
+	mock.Mock
+}
+
+// MockBase mocks the Base interface
+type MockBase[T any] struct {
+	mock.Mock
+}
+
+// MockStore mocks the Store interface
+type MockStore[K any, V any, N ~int | ~string | float64, S ~[]K, C interface {
+	comparable
+	String() string
+}] struct {
+	mock.Mock
+}
+
+// MockY mocks the Y interface
+type MockY[T any] struct {
+	mock.Mock
+	MockX
+	MockBase[T]
+	gen.MockPair[T, string]
+}
+
+// Flavour mocks the Flavour function
+func (mock *MockX) Flavour() (r0 string) {
+	args := mock.Called()
+
+	if rf, ok := args.Get(0).(func() string); ok {
+		return rf()
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(string)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected string")
+		}
+	}
+	return r0
+}
+
+// Get mocks the Get function
+func (mock *MockBase[T]) Get() (r0 T) {
+	args := mock.Called()
+
+	if rf, ok := args.Get(0).(func() T); ok {
+		return rf()
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(T)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected T")
+		}
+	}
+	return r0
+}
+
+// Put mocks the Put function
+func (mock *MockStore[K, V, N, S, C]) Put(k K, v V) (r0 N, r1 S, r2 C) {
+	args := mock.Called(k, v)
+
+	if rf, ok := args.Get(0).(func(K, V) (N, S, C)); ok {
+		return rf(k, v)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(N)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected N")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(S)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected S")
+		}
+	}
+
+	if args.Get(2) != nil {
+		argOk := false
+		r2, argOk = args.Get(2).(C)
+		if !argOk {
+			panic("incorrect type supplied for return value [2], expected C")
+		}
+	}
+	return r0, r1, r2
+}
+
+// Name mocks the Name function
+func (mock *MockY[T]) Name() (r0 name.Name) {
+	args := mock.Called()
+
+	if rf, ok := args.Get(0).(func() name.Name); ok {
+		return rf()
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(name.Name)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected name.Name")
+		}
+	}
+	return r0
+}
+
+// YYY mocks the YYY function
+func (mock *MockY[T]) YYY(x int, y string, b bool) (r0 int, r1 error) {
+	args := mock.Called(x, y, b)
+
+	if rf, ok := args.Get(0).(func(int, string, bool) (int, error)); ok {
+		return rf(x, y, b)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(int)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected int")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected error")
+		}
+	}
+	return r0, r1
+}
+
+// ZZZ mocks the ZZZ function
+func (mock *MockY[T]) ZZZ(x *int) (r0 int, r1 error) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func(*int) (int, error)); ok {
+		return rf(x)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(int)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected int")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected error")
+		}
+	}
+	return r0, r1
+}
+
+// AAAA mocks the AAAA function
+func (mock *MockY[T]) AAAA(x *int) (r0 *int, r1 error) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func(*int) (*int, error)); ok {
+		return rf(x)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(*int)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected *int")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected error")
+		}
+	}
+	return r0, r1
+}
+
+// BBBB mocks the BBBB function
+func (mock *MockY[T]) BBBB(x map[string]*int) (r0 *int, r1 error) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func(map[string]*int) (*int, error)); ok {
+		return rf(x)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(*int)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected *int")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected error")
+		}
+	}
+	return r0, r1
+}
+
+// CCCC mocks the CCCC function
+func (mock *MockY[T]) CCCC(x []name.Name) (r0 *int, r1 error) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func([]name.Name) (*int, error)); ok {
+		return rf(x)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(*int)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected *int")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected error")
+		}
+	}
+	return r0, r1
+}
+
+// DDDD mocks the DDDD function
+func (mock *MockY[T]) DDDD(x ...name.Name) (r0 *int, r1 error) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func(...name.Name) (*int, error)); ok {
+		return rf(x...)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(*int)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected *int")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected error")
+		}
+	}
+	return r0, r1
+}
+
+// EEEE mocks the EEEE function
+func (mock *MockY[T]) EEEE(x func(int, string) error) (r0 *int, r1 error) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func(func(int, string) error) (*int, error)); ok {
+		return rf(x)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(*int)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected *int")
+		}
+	}
+
+	if args.Get(1) != nil {
+		argOk := false
+		r1, argOk = args.Get(1).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [1], expected error")
+		}
+	}
+	return r0, r1
+}
+
+// FFFF mocks the FFFF function
+func (mock *MockY[T]) FFFF(y T) (r0 error) {
+	args := mock.Called(y)
+
+	if rf, ok := args.Get(0).(func(T) error); ok {
+		return rf(y)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(error)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected error")
+		}
+	}
+	return r0
+}
+
+// GGGG mocks the GGGG function
+func (mock *MockY[T]) GGGG(y T) (r0 map[string]T) {
+	args := mock.Called(y)
+
+	if rf, ok := args.Get(0).(func(T) map[string]T); ok {
+		return rf(y)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(map[string]T)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected map[string]T")
+		}
+	}
+	return r0
+}
+
+// HHHH mocks the HHHH function
+func (mock *MockY[T]) HHHH(y T) (r0 gen.Generic[name.Name, string]) {
+	args := mock.Called(y)
+
+	if rf, ok := args.Get(0).(func(T) gen.Generic[name.Name, string]); ok {
+		return rf(y)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(gen.Generic[name.Name, string])
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected gen.Generic[name.Name, string]")
+		}
+	}
+	return r0
+}
+
+// IIII mocks the IIII function
+func (mock *MockY[T]) IIII(x chan<- T, y <-chan *int, z chan (<-chan int)) (r0 chan name.Name) {
+	args := mock.Called(x, y, z)
+
+	if rf, ok := args.Get(0).(func(chan<- T, <-chan *int, chan (<-chan int)) chan name.Name); ok {
+		return rf(x, y, z)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(chan name.Name)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected chan name.Name")
+		}
+	}
+	return r0
+}
+
+// JJJJ mocks the JJJJ function
+func (mock *MockY[T]) JJJJ(x [sha256.Size]byte, y [2 * size][]T) (r0 [32]byte) {
+	args := mock.Called(x, y)
+
+	if rf, ok := args.Get(0).(func([sha256.Size]byte, [2 * size][]T) [32]byte); ok {
+		return rf(x, y)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).([32]byte)
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected [32]byte")
+		}
+	}
+	return r0
+}
+
+// KKKK mocks the KKKK function
+func (mock *MockY[T]) KKKK(x interface{ Close() error }, y interface{}) (r0 struct {
+	Hits, Misses int ` + "`json:\"hits\"`" + `
+	Name         string
+}) {
+	args := mock.Called(x, y)
+
+	if rf, ok := args.Get(0).(func(interface{ Close() error }, interface{}) struct {
+		Hits, Misses int ` + "`json:\"hits\"`" + `
+		Name         string
+	}); ok {
+		return rf(x, y)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(struct {
+			Hits, Misses int ` + "`json:\"hits\"`" + `
+			Name         string
+		})
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected struct{ Hits, Misses int ` + "`json:\\\"hits\\\"`" + `; Name string }")
+		}
+	}
+	return r0
+}
+
+// LLLL mocks the LLLL function
+func (mock *MockY[T]) LLLL(x struct{}) (r0 interface {
+	name.Namer
+	Len() int
+}) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func(struct{}) interface {
+		name.Namer
+		Len() int
+	}); ok {
+		return rf(x)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(interface {
+			name.Namer
+			Len() int
+		})
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected interface{ name.Namer; Len() int }")
+		}
+	}
+	return r0
+}
+
+// MMMM mocks the MMMM function
+func (mock *MockY[T]) MMMM(x gen.Option[T]) (r0 Base[name.Name]) {
+	args := mock.Called(x)
+
+	if rf, ok := args.Get(0).(func(gen.Option[T]) Base[name.Name]); ok {
+		return rf(x)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(Base[name.Name])
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected Base[name.Name]")
+		}
+	}
+	return r0
+}
+
+// NNNN mocks the NNNN function
+func (mock *MockY[T]) NNNN(a func(int), b func(...string) (err error), c func() (a int, b int), d func(func(int) bool) func() error) (r0 interface{ Close() }) {
+	args := mock.Called(a, b, c, d)
+
+	if rf, ok := args.Get(0).(func(func(int), func(...string) (err error), func() (a int, b int), func(func(int) bool) func() error) interface{ Close() }); ok {
+		return rf(a, b, c, d)
+	}
+
+	if args.Get(0) != nil {
+		argOk := false
+		r0, argOk = args.Get(0).(interface{ Close() })
+		if !argOk {
+			panic("incorrect type supplied for return value [0], expected interface{ Close() }")
+		}
+	}
+	return r0
+}
+`
+
+	ret, err := writeMock(templateData, NewFileWriter(), "test_mock.go")
+	assert.NoError(t, err)
+	assert.Equal(t, expectedFile, string(ret))
+}
+
+func TestConstructor(t *testing.T) {
+	templateData := &TemplateData{
+		Package:     "foo",
+		Constructor: true,
+		Interfaces: []*Interface{
+			{Name: "Store"},
+			{Name: "Y", MockName: "yMock", Embedded: []string{"Base[T]", "gen.Pair[T, string]"}, Generics: []*Param{{Name: "T", Type: "any"}}},
+		},
+	}
+
+	ret, err := writeMock(templateData, NewFileWriter(), "test_mock.go")
+	assert.NoError(t, err)
+	assert.Contains(t, string(ret), `// NewMockStore creates a MockStore that fails the test if its expectations weren't met once it finishes
+func NewMockStore(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockStore {
+	m := &MockStore{}
+	m.Mock.Test(t)
+
+	t.Cleanup(func() {
+		m.Mock.AssertExpectations(t)
+	})
+
+	return m
+}`)
+	assert.Contains(t, string(ret), `func newYMock[T any](t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *yMock[T] {
+	m := &yMock[T]{}
+	m.Mock.Test(t)
+	m.MockBase.Test(t)
+	m.MockPair.Test(t)
+
+	t.Cleanup(func() {
+		m.Mock.AssertExpectations(t)
+		m.MockBase.AssertExpectations(t)
+		m.MockPair.AssertExpectations(t)
+	})
+
+	return m
+}`)
+}
+
+func TestExpecter(t *testing.T) {
+	templateData := &TemplateData{
+		Package:  "foo",
+		Expecter: true,
+		Interfaces: []*Interface{
+			{
+				Name:     "Y",
+				Generics: []*Param{{Name: "T", Type: "any"}},
+				Funcs: []*Func{
+					{
+						Name:   "Sum",
+						Params: []*Param{{Name: "", Type: "string"}, {Name: "xs", Type: "...T"}},
+						Return: []*Param{{Name: "", Type: "T"}, {Name: "", Type: "error"}},
+					},
+					{
+						Name:   "Read",
+						Params: []*Param{{Name: "p", Type: "[]byte"}},
+						Return: []*Param{{Name: "n", Type: "int"}, {Name: "err", Type: "error"}},
+					},
+				},
+			},
+		},
+	}
+
+	ret, err := writeMock(templateData, NewFileWriter(), "test_mock.go")
+	assert.NoError(t, err)
+	assert.Contains(t, string(ret), `// EXPECT returns the typed builders for the expectations of the mock
+func (mock *MockY[T]) EXPECT() *MockYExpecter[T] {
+	return &MockYExpecter[T]{mock: &mock.Mock}
+}`)
+	assert.Contains(t, string(ret), `func (_e *MockYExpecter[T]) Sum(p0 interface{}, xs interface{}) *MockYSumCall[T] {
+	return &MockYSumCall[T]{Call: _e.mock.On("Sum", p0, xs)}
+}`)
+	assert.Contains(t, string(ret), `func (_c *MockYSumCall[T]) Return(r0 T, r1 error) *MockYSumCall[T] {
+	_c.Call.Return(r0, r1)
+	return _c
+}`)
+	assert.Contains(t, string(ret), `func (_c *MockYSumCall[T]) Run(run func(p0 string, xs ...T)) *MockYSumCall[T] {
+	_c.Call.Run(func(args mock.Arguments) {
+		a0, _ := args.Get(0).(string)
+		a1, _ := args.Get(1).([]T)
+		run(a0, a1...)
+	})
+	return _c
+}`)
+	assert.Contains(t, string(ret), `func (_c *MockYSumCall[T]) RunAndReturn(run func(string, ...T) (T, error)) *MockYSumCall[T] {
+	_c.Call.Return(run)
+	return _c
+}`)
+	assert.Contains(t, string(ret), `	if rf, ok := args.Get(0).(func(string, ...T) (T, error)); ok {
+		return rf(p0, xs...)
+	}`)
+	assert.Contains(t, string(ret), `func (_c *MockYReadCall[T]) Return(r0 int, r1 error) *MockYReadCall[T] {
+	_c.Call.Return(r0, r1)
+	return _c
+}`)
+}
+
+func TestShadowedParams(t *testing.T) {
+	templateData := &TemplateData{
+		Package: "foo",
+		Imports: []string{"\"net/url\""},
+		Interfaces: []*Interface{
+			{
+				Name: "Client",
+				Funcs: []*Func{
+					{
+						Name:   "Fetch",
+						Params: []*Param{{Name: "url", Type: "*url.URL"}, {Name: "path", Type: "string"}},
+						Return: []*Param{{Name: "", Type: "error"}},
+					},
+					{
+						Name:   "Set",
+						Params: []*Param{{Name: "key", Type: "string"}, {Name: "ok", Type: "bool"}, {Name: "_", Type: "int"}},
+						Return: []*Param{{Name: "", Type: "error"}},
+					},
+					{
+						Name:   "Apply",
+						Params: []*Param{{Name: "rf", Type: "func()"}, {Name: "args", Type: "[]string"}, {Name: "mock", Type: "bool"}, {Name: "r0", Type: "int"}},
+						Return: []*Param{{Name: "", Type: "error"}},
+					},
+				},
+			},
+		},
+	}
+
+	ret, err := writeMock(templateData, NewFileWriter(), "test_mock.go")
+	assert.NoError(t, err)
+	assert.Contains(t, string(ret), `// Fetch mocks the Fetch function
+func (mock *MockClient) Fetch(p0 *url.URL, path string) (r0 error) {
+	args := mock.Called(p0, path)
+
+	if rf, ok := args.Get(0).(func(*url.URL, string) error); ok {
+		return rf(p0, path)
+	}
+`)
+	assert.Contains(t, string(ret), `func (mock *MockClient) Set(key string, p1 bool, p2 int) (r0 error) {
+	args := mock.Called(key, p1, p2)
+
+	if rf, ok := args.Get(0).(func(string, bool, int) error); ok {
+		return rf(key, p1, p2)
+	}
+`)
+	assert.Contains(t, string(ret), `func (mock *MockClient) Apply(p0 func(), p1 []string, p2 bool, p3 int) (r0 error) {
+	args := mock.Called(p0, p1, p2, p3)
+`)
+}
+
+func TestExpecterCompiles(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go not found")
+	}
+
+	// A stub of testify/mock, so the mocks can be vetted without the module
+	// cache or network
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod":         "module example.com/foo\n\ngo 1.18\n\nrequire github.com/stretchr/testify v1.8.1\n\nreplace github.com/stretchr/testify => ./testify\n",
+		"testify/go.mod": "module github.com/stretchr/testify\n\ngo 1.18\n",
+		"testify/mock/mock.go": `package mock
+
+type TestingT interface {
+	Logf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+	FailNow()
+}
+
+type Arguments []interface{}
+
+func (a Arguments) Get(i int) interface{} { return a[i] }
+
+type Call struct{}
+
+func (c *Call) Return(returnArguments ...interface{}) *Call { return c }
+
+func (c *Call) Run(fn func(args Arguments)) *Call { return c }
+
+type Mock struct{}
+
+func (m *Mock) On(methodName string, arguments ...interface{}) *Call { return &Call{} }
+
+func (m *Mock) Called(arguments ...interface{}) Arguments { return nil }
+
+func (m *Mock) Test(t TestingT) {}
+
+func (m *Mock) AssertExpectations(t TestingT) bool { return true }
+`,
+		"foo.go": `package foo
+
+import (
+	"io"
+	"net/url"
+)
+
+type Store[T any] interface {
+	io.Reader
+	Get(key string) (value T, err error)
+	Sum(xs ...int) (total int)
+	Do(func(n int) error)
+	Fetch(url *url.URL) error
+	Set(key string, ok bool) error
+	Apply(rf func(), args []string) error
+}
+`,
+	})
+
+	opts := DefaultOptions()
+	opts.Pkg = dir
+	opts.Expecter = true
+	files, err := Generate(context.Background(), opts)
+	require.NoError(t, err)
+	require.NoError(t, WriteFiles(files))
+
+	cmd := exec.Command("go", "vet", ".")
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off")
+	out, err := cmd.CombinedOutput()
+	assert.NoError(t, err, string(out))
+}
+
+func TestAssert(t *testing.T) {
+	testFileSrc := `package foo
+
+	type Number interface {
+		~int | ~float64
+	}
+
+	type Ordered interface {
+		comparable
+		Less() bool
+	}
+
+	type Sum interface {
+		Number
+	}
+
+	type Store interface {
+		Get() string
+	}
+
+	type Y[T Number] interface {
+		Sum(xs ...T) T
+	}
+
+	type hidden interface {
+		Do()
+	}
+	`
+
+	fset := token.NewFileSet()
+	f, err := parser.ParseFile(fset, "", testFileSrc, parser.ParseComments)
+	assert.NoError(t, err)
+
+	templateData := Parse(f)
+	Flatten(templateData, templateData.Interfaces)
+	templateData.Assert = true
+
+	constraints := []bool{}
+	for _, inter := range templateData.Interfaces {
+		constraints = append(constraints, inter.Constraint)
+	}
+	assert.Equal(t, []bool{true, true, true, false, false, false}, constraints)
+	assert.Nil(t, templateData.Interfaces[1].Embedded)
+
+	ret, err := writeMock(templateData, NewFileWriter(), "test_mock.go")
+	assert.NoError(t, err)
+	assert.Contains(t, string(ret), "var _ Store = (*MockStore)(nil)")
+	assert.Contains(t, string(ret), "func _[T Number]() {\n\tvar _ Y[T] = (*MockY[T])(nil)\n}")
+	assert.Contains(t, string(ret), "var _ hidden = (*Mockhidden)(nil)")
+	assert.NotContains(t, string(ret), "var _ Number")
+	assert.NotContains(t, string(ret), "var _ Ordered")
+	assert.NotContains(t, string(ret), "var _ Sum")
+
+	templateData.SourcePackage = "foo"
+	ret, err = writeMock(templateData, NewFileWriter(), "test_mock.go")
+	assert.NoError(t, err)
+	assert.Contains(t, string(ret), "var _ foo.Store = (*MockStore)(nil)")
+	assert.NotContains(t, string(ret), "hidden = ")
+}
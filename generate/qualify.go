@@ -0,0 +1,210 @@
+package generate
+
+import (
+	"context"
+	"fmt"
+	"go/ast"
+	"go/scanner"
+	"go/token"
+
+	"golang.org/x/tools/go/ast/astutil"
+	"golang.org/x/tools/go/packages"
+)
+
+// Qualify rewrites the interfaces declared at the top level of f so that every
+// reference to one of the declared types or constants of their own package is
+// qualified with name, e.g. Config becomes foo.Config, for mocks written to
+// another package. Type parameters are left alone, as are the embedded
+// interfaces themselves, which are resolved by name to their methods or mocks,
+// though not their type arguments.
+func Qualify(f *ast.File, name string, declared map[string]bool) {
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			it, ok := ts.Type.(*ast.InterfaceType)
+			if !ok {
+				continue
+			}
+
+			q := &qualifier{name: name, declared: declared, params: map[string]bool{}}
+			if ts.TypeParams != nil {
+				for _, tp := range ts.TypeParams.List {
+					for _, n := range tp.Names {
+						q.params[n.Name] = true
+					}
+				}
+
+				for _, tp := range ts.TypeParams.List {
+					tp.Type = q.expr(tp.Type)
+				}
+			}
+
+			for _, method := range it.Methods.List {
+				if len(method.Names) > 0 {
+					method.Type = q.expr(method.Type)
+					continue
+				}
+
+				switch t := method.Type.(type) {
+				case *ast.IndexExpr:
+					t.Index = q.expr(t.Index)
+				case *ast.IndexListExpr:
+					for i := range t.Indices {
+						t.Indices[i] = q.expr(t.Indices[i])
+					}
+				}
+			}
+		}
+	}
+}
+
+type qualifier struct {
+	name     string
+	declared map[string]bool
+	params   map[string]bool
+}
+
+func (q *qualifier) expr(e ast.Expr) ast.Expr {
+	return astutil.Apply(e, func(c *astutil.Cursor) bool {
+		switch n := c.Node().(type) {
+		case *ast.SelectorExpr:
+			// Already qualified
+			return false
+		case *ast.Ident:
+			// Parameter, field and method names
+			if c.Name() == "Names" {
+				return false
+			}
+
+			if q.declared[n.Name] && !q.params[n.Name] {
+				c.Replace(&ast.SelectorExpr{X: ast.NewIdent(q.name), Sel: ast.NewIdent(n.Name)})
+			}
+		}
+
+		return true
+	}, nil).(ast.Expr)
+}
+
+// declaredNames returns the types and constants declared at the top level of
+// files. Unexported ones are qualified too, though another package can't refer
+// to them, so the mocks that do are reported by unexportedRefs.
+func declaredNames(files []*ast.File) map[string]bool {
+	declared := map[string]bool{}
+	for _, f := range files {
+		for _, decl := range f.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || (gen.Tok != token.TYPE && gen.Tok != token.CONST) {
+				continue
+			}
+
+			for _, spec := range gen.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					declared[s.Name.Name] = true
+				case *ast.ValueSpec:
+					for _, n := range s.Names {
+						declared[n.Name] = true
+					}
+				}
+			}
+		}
+	}
+
+	return declared
+}
+
+// unexportedRefs returns the unexported types and constants of the package
+// named pkg that params refer to, e.g. hidden in foo.hidden, which a mock in
+// another package can't.
+func unexportedRefs(params []*Param, pkg string) []string {
+	refs := make([]string, 0)
+	scanSelectors(params, func(qual, sel string) {
+		if qual == pkg && !token.IsExported(sel) && !contains(refs, sel) {
+			refs = append(refs, sel)
+		}
+	})
+
+	return refs
+}
+
+// qualifiers returns the packages the types of params refer to, e.g. url in
+// *url.URL.
+func qualifiers(params []*Param) []string {
+	quals := make([]string, 0)
+	scanSelectors(params, func(qual, _ string) {
+		if !contains(quals, qual) {
+			quals = append(quals, qual)
+		}
+	})
+
+	return quals
+}
+
+// scanSelectors calls fn with each qualified identifier in the types of params,
+// e.g. foo and Bar for foo.Bar.
+func scanSelectors(params []*Param, fn func(qual, sel string)) {
+	for _, p := range params {
+		fset := token.NewFileSet()
+		file := fset.AddFile("", fset.Base(), len(p.Type))
+
+		var s scanner.Scanner
+		s.Init(file, []byte(p.Type), nil, 0)
+
+		var (
+			last          token.Token
+			lastLit, qual string
+		)
+
+		for {
+			_, tok, lit := s.Scan()
+			if tok == token.EOF {
+				break
+			}
+
+			if tok == token.IDENT && last == token.PERIOD {
+				fn(qual, lit)
+			}
+
+			if tok == token.PERIOD {
+				qual = lastLit
+			}
+
+			last, lastLit = tok, lit
+		}
+	}
+}
+
+// importPath returns the import path of the package in dir.
+func importPath(ctx context.Context, dir string) (string, error) {
+	pkgs, err := packages.Load(&packages.Config{Context: ctx, Mode: packages.NeedName, Dir: dir}, ".")
+	if err != nil {
+		return "", err
+	}
+
+	if len(pkgs) != 1 || pkgs[0].PkgPath == "" {
+		return "", fmt.Errorf("no import path found for %s", dir)
+	}
+
+	return pkgs[0].PkgPath, nil
+}
+
+// relocate moves tempData to the package outPkg, importing the source package
+// named name from importPath, which its qualified types refer to.
+func relocate(tempData *TemplateData, outPkg, name, importPath string) {
+	tempData.Package = outPkg
+	tempData.SourcePackage = name
+
+	impo := formatImport(name, importPath)
+	if !contains(tempData.Imports, impo) {
+		tempData.Imports = append(tempData.Imports, impo)
+	}
+}
@@ -0,0 +1,93 @@
+package generate
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQualify(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"config.go": `package foo
+
+const Size = 4
+
+type Config struct{}
+
+type Constraint interface{ ~int }
+
+type hidden struct{}
+`,
+		"foo.go": `package foo
+
+import "context"
+
+type Base[T any] interface {
+	Get() T
+}
+
+type Pair[K, V any] interface {
+	Set(k K, v V)
+}
+
+type Y[T Constraint, Config any] interface {
+	Base[Config]
+	Pair[[Size]T, hidden]
+	Load(ctx context.Context, Config Config) (*Config, error)
+	All(opts struct{ Config string }) map[string][]T
+}
+`,
+	})
+
+	for _, types := range []bool{false, true} {
+		opts := &Options{OutPkg: "mocks", Types: types}
+		pkg, data, err := parseDir(context.Background(), opts, dir)
+		require.NoError(t, err)
+
+		tempData := MergeTemplateData(data)
+		assert.Equal(t, "mocks", tempData.Package)
+		assert.Contains(t, tempData.Imports, `"example.com/foo"`)
+
+		y := tempData.Interfaces[3]
+		assert.Equal(t, []*Param{{Name: "T", Type: "foo.Constraint"}, {Name: "Config", Type: "any"}}, y.Generics)
+		assert.Equal(t, []string{"Base[Config]", "Pair[[foo.Size]T, foo.hidden]"}, y.Embedded)
+		assert.Equal(t, []*Param{{Name: "ctx", Type: "context.Context"}, {Name: "Config", Type: "Config"}}, y.Funcs[0].Params)
+		assert.Equal(t, []*Param{{Name: "", Type: "*Config"}, {Name: "", Type: "error"}}, y.Funcs[0].Return)
+		assert.NotContains(t, y.Funcs[1].Params[0].Type, "foo.")
+		assert.Equal(t, "foo", pkg.Name)
+	}
+}
+
+func TestGeneratePackageOutPkg(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+type Config struct{}
+
+type Store interface {
+	Load() (Config, error)
+}
+`,
+	})
+
+	files, err := generatePackage(context.Background(), &Options{OutPkg: "mocks", Flatten: true}, dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, filepath.Join(dir, "mocks", "foo_mock.go"), files[0].Path)
+	assert.Contains(t, string(files[0].Content), "package mocks")
+	assert.Contains(t, string(files[0].Content), `"example.com/foo"`)
+	assert.Contains(t, string(files[0].Content), "func (mock *MockStore) Load() (r0 foo.Config, r1 error)")
+
+	files, err = generatePackage(context.Background(), &Options{OutPkg: "foo_test", PerFile: true, Flatten: true}, dir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, filepath.Join(dir, "foo_mock_test.go"), files[0].Path)
+	assert.Contains(t, string(files[0].Content), "package foo_test")
+}
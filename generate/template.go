@@ -0,0 +1,495 @@
+package generate
+
+import (
+	"bytes"
+	"fmt"
+	"go/token"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"golang.org/x/tools/imports"
+)
+
+var templateContent string = `{{- $global := . -}}
+{{- if .Header }}// Code generated by 'ridicule' DO NOT EDIT.
+//
+// ######   #####     ######   #####  #######    ####### ######  ####### #######
+// ####### #######    ####### ####### #######    ####### ####### ####### #######
+// ### ### ### ###    ### ### ### ###   ###      ###     ### ###   ###     ###
+// ### ### ### ###    ### ### ### ###   ###      ####### ### ###   ###     ###
+// ### ### ### ###    ### ### ### ###   ###      ###     ### ###   ###     ###
+// ####### #######    ### ### #######   ###      ####### ####### #######   ###
+// ######   #####     ### ###  #####    ###      ####### ######  #######   ###
+//
+// *** DO NOT EDIT *** This file was generated by 'ridicule' *** DO NOT EDIT ***
+
+{{end}}package {{ .Package }}
+
+import (
+	"github.com/stretchr/testify/mock"
+	{{- range .Imports }}
+	{{ . }}
+	{{- end }}
+)
+{{ range $interface := .Interfaces }}
+// {{ $interface.MockName }} mocks the {{ $interface.Name }} interface
+type {{ $interface.MockName }}{{if len $interface.Generics }}[{{ formatParams $interface.Generics "" }}]{{end}} struct {
+	mock.Mock
+	{{- range .EmbeddedMocks }}
+	{{ . }}
+	{{- end }}
+}
+{{- if $.Constructor }}
+
+// {{ constructorName $interface.MockName }} creates a {{ $interface.MockName }} that fails the test if its expectations weren't met once it finishes
+func {{ constructorName $interface.MockName }}{{if len $interface.Generics }}[{{ formatParams $interface.Generics "" }}]{{end}}(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *{{ $interface.MockName }}{{if len $interface.Generics }}[{{ formatGenerics $interface.Generics }}]{{end}} {
+	m := &{{ $interface.MockName }}{{if len $interface.Generics }}[{{ formatGenerics $interface.Generics }}]{{end}}{}
+	m.Mock.Test(t)
+	{{- range .EmbeddedMocks }}
+	m.{{ embeddedField . }}.Test(t)
+	{{- end }}
+
+	t.Cleanup(func() {
+		m.Mock.AssertExpectations(t)
+		{{- range .EmbeddedMocks }}
+		m.{{ embeddedField . }}.AssertExpectations(t)
+		{{- end }}
+	})
+
+	return m
+}
+{{- end }}
+{{- if and $.Assert (not $interface.Constraint) }}
+{{- with interfaceRef $ $interface }}
+{{ if len $interface.Generics }}
+func _[{{ formatParams $interface.Generics "" }}]() {
+	var _ {{ . }}[{{ formatGenerics $interface.Generics }}] = (*{{ $interface.MockName }}[{{ formatGenerics $interface.Generics }}])(nil)
+}
+{{- else }}
+var _ {{ . }} = (*{{ $interface.MockName }})(nil)
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- range $interface := .Interfaces }}
+{{- range $f := $interface.Funcs }}
+
+{{- $params := methodParams $f }}
+
+// {{ $f.Name }} mocks the {{ $f.Name }} function
+func (mock *{{ $interface.MockName }}{{if len $interface.Generics }}[{{ formatGenerics $interface.Generics }}]{{end}}) {{ $f.Name }}({{ formatParams $params "p" }}){{ formatReturnParams $f.Return }} {
+	{{- if not $f.Return }}
+	mock.Called({{ formatNames $params }})
+	{{- else }}
+	args := mock.Called({{ formatNames $params }})
+
+	if rf, ok := args.Get(0).({{ formatFuncType $params $f.Return }}); ok {
+		return rf({{ formatCallNames $params }})
+	}
+	{{- end }}
+	{{- range $i, $r := $f.Return }}
+
+	if args.Get({{ $i }}) != nil {
+		argOk := false
+		r{{ $i }}, argOk = args.Get({{ $i }}).({{ $r.Type }})
+		if !argOk {
+			panic("incorrect type supplied for return value [{{ $i }}], expected {{ escape $r.Type }}")
+		}
+	}
+	{{- end }}{{ if $f.Return }}
+	return {{ formatReturn $f.Return }}{{- end }}
+}
+{{- end }}
+{{- if $.Expecter }}
+{{- $tp := "" }}
+{{- $ta := "" }}
+{{- if len $interface.Generics }}
+{{- $tp = printf "[%s]" (formatParams $interface.Generics "") }}
+{{- $ta = printf "[%s]" (formatGenerics $interface.Generics) }}
+{{- end }}
+
+// {{ $interface.MockName }}Expecter sets the expectations of a {{ $interface.MockName }} with typed builders
+type {{ $interface.MockName }}Expecter{{ $tp }} struct {
+	mock *mock.Mock
+}
+
+// EXPECT returns the typed builders for the expectations of the mock
+func (mock *{{ $interface.MockName }}{{ $ta }}) EXPECT() *{{ $interface.MockName }}Expecter{{ $ta }} {
+	return &{{ $interface.MockName }}Expecter{{ $ta }}{mock: &mock.Mock}
+}
+{{- range $f := $interface.Funcs }}
+{{- $call := printf "%s%sCall" $interface.MockName $f.Name }}
+
+// {{ $call }} is an expected call to {{ $f.Name }}
+type {{ $call }}{{ $tp }} struct {
+	*mock.Call
+}
+
+// {{ $f.Name }} expects a call to {{ $f.Name }} with arguments matching the given values or matchers, e.g. mock.Anything
+func (_e *{{ $interface.MockName }}Expecter{{ $ta }}) {{ $f.Name }}({{ formatExpectArgs $f.Params }}) *{{ $call }}{{ $ta }} {
+	return &{{ $call }}{{ $ta }}{Call: _e.mock.On("{{ $f.Name }}"{{ if $f.Params }}, {{ formatNames $f.Params }}{{ end }})}
+}
+
+// Return sets the values returned by the call
+func (_c *{{ $call }}{{ $ta }}) Return({{ formatResults $f.Return }}) *{{ $call }}{{ $ta }} {
+	_c.Call.Return({{ formatReturn $f.Return }})
+	return _c
+}
+
+// Run sets a function called with the arguments of the call
+func (_c *{{ $call }}{{ $ta }}) Run(run func({{ formatParams $f.Params "p" }})) *{{ $call }}{{ $ta }} {
+	_c.Call.Run(func(args mock.Arguments) {
+		{{- range $i, $p := $f.Params }}
+		a{{ $i }}, _ := args.Get({{ $i }}).({{ variadicSlice $p.Type }})
+		{{- end }}
+		run({{ formatRunArgs $f.Params }})
+	})
+	return _c
+}
+
+// RunAndReturn sets a function computing the values returned by the call from its arguments
+func (_c *{{ $call }}{{ $ta }}) RunAndReturn(run {{ formatFuncType $f.Params $f.Return }}) *{{ $call }}{{ $ta }} {
+	{{- if $f.Return }}
+	_c.Call.Return(run)
+	{{- else }}
+	_c.Call.Run(func(args mock.Arguments) {
+		{{- range $i, $p := $f.Params }}
+		a{{ $i }}, _ := args.Get({{ $i }}).({{ variadicSlice $p.Type }})
+		{{- end }}
+		run({{ formatRunArgs $f.Params }})
+	})
+	{{- end }}
+	return _c
+}
+{{- end }}
+{{- end }}
+{{- end }}
+`
+
+// FileWriter renders mock files from template data.
+type FileWriter struct {
+	template *template.Template
+}
+
+// NewFileWriter parses the mock template.
+func NewFileWriter() *FileWriter {
+	funcMap := template.FuncMap{
+		"add": func(x, y int) int {
+			return x + y
+		},
+		"formatParams":       formatParams,
+		"formatGenerics":     formatGenerics,
+		"formatReturnParams": formatReturnParams,
+		"formatNames":        formatNames,
+		"formatResults":      formatResults,
+		"formatReturn":       formatReturn,
+		"escape":             escape,
+		"constructorName":    constructorName,
+		"embeddedField":      embeddedField,
+		"formatExpectArgs":   formatExpectArgs,
+		"formatFuncType":     formatFuncType,
+		"formatRunArgs":      formatRunArgs,
+		"formatCallNames":    formatCallNames,
+		"variadicSlice":      variadicSlice,
+		"interfaceRef":       interfaceRef,
+		"methodParams":       methodParams,
+	}
+	template := template.Must(
+		template.New("mock.tmpl").Funcs(funcMap).Parse(templateContent),
+	)
+
+	return &FileWriter{template}
+}
+
+// WriteMock renders the mocks of tempData and writes them to outPath.
+func (f *FileWriter) WriteMock(outPath string, tempData *TemplateData) error {
+	out, err := writeMock(tempData, f, outPath)
+	if err != nil {
+		return err
+	}
+
+	return WriteFiles([]GeneratedFile{{Path: outPath, Content: out}})
+}
+
+// Render renders the mocks of tempData as the formatted contents of the file
+// at outPath, without writing it.
+func (f *FileWriter) Render(outPath string, tempData *TemplateData) ([]byte, error) {
+	return writeMock(tempData, f, outPath)
+}
+
+func writeMock(tempData *TemplateData, file *FileWriter, outPath string) ([]byte, error) {
+	// Mocks that weren't prepared take the default name
+	for _, inter := range tempData.Interfaces {
+		if inter.MockName == "" || len(inter.EmbeddedMocks) != len(inter.Embedded) {
+			if err := NameMocks(&TemplateData{Interfaces: []*Interface{inter}}, DefaultNaming, tempData.Interfaces); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	var buff bytes.Buffer
+	err := file.template.Execute(&buff, tempData)
+	if err != nil {
+		return nil, &TemplateError{Path: outPath, Err: err}
+	}
+
+	out, err := imports.Process(filepath.Base(outPath), buff.Bytes(), &imports.Options{Comments: true})
+	if err != nil {
+		return nil, &TemplateError{Path: outPath, Err: err}
+	}
+
+	return out, nil
+}
+
+func formatParams(params []*Param, prefix string) string {
+	formatted := make([]string, 0)
+	for i, param := range params {
+		p := []string{}
+		if !isEmptyOrWhitespace(param.Name) {
+			p = append(p, param.Name)
+		} else {
+			p = append(p, fmt.Sprintf("%s%d", prefix, i))
+		}
+		if !isEmptyOrWhitespace(param.Type) {
+			p = append(p, param.Type)
+		}
+
+		formatted = append(formatted, strings.Join(p, " "))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func formatGenerics(params []*Param) string {
+	formatted := make([]string, 0)
+	for _, param := range params {
+		formatted = append(formatted, param.Name)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func formatReturnParams(params []*Param) string {
+	formattedStr := formatResults(params)
+
+	if formattedStr == "" {
+		return ""
+	}
+
+	if strings.Contains(formattedStr, " ") {
+		return " (" + formattedStr + ")"
+	}
+
+	return " " + formattedStr
+}
+
+// formatResults formats results as parameters named r0, r1 and so on, matching
+// formatReturn whatever names they were declared with.
+func formatResults(params []*Param) string {
+	formatted := make([]string, 0)
+	for i, param := range params {
+		paramStr := []string{}
+		paramStr = append(paramStr, fmt.Sprintf("r%d", i))
+		if !isEmptyOrWhitespace(param.Type) {
+			paramStr = append(paramStr, param.Type)
+		}
+
+		formatted = append(formatted, strings.Join(paramStr, " "))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func formatNames(params []*Param) string {
+	formatted := make([]string, 0)
+	for i, param := range params {
+		if param.Name != "" {
+			formatted = append(formatted, param.Name)
+		} else {
+			formatted = append(formatted, fmt.Sprintf("p%d", i))
+		}
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// formatCallNames formats the parameter names a mocked method passes on to a
+// function with the same signature, spreading the final one when variadic.
+func formatCallNames(params []*Param) string {
+	formatted := make([]string, 0)
+	for i, param := range params {
+		name := param.Name
+		if name == "" {
+			name = fmt.Sprintf("p%d", i)
+		}
+
+		if strings.HasPrefix(param.Type, "...") {
+			name += "..."
+		}
+
+		formatted = append(formatted, name)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+func formatReturn(params []*Param) string {
+	formatted := make([]string, 0)
+	for i := range params {
+		formatted = append(formatted, fmt.Sprintf("r%d", i))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// formatExpectArgs formats the parameters of an expectation builder, each of
+// which accepts either a value or a matcher.
+func formatExpectArgs(params []*Param) string {
+	formatted := make([]string, 0)
+	for i, param := range params {
+		name := param.Name
+		if name == "" {
+			name = fmt.Sprintf("p%d", i)
+		}
+
+		formatted = append(formatted, name+" interface{}")
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// bodyNames are the identifiers a mocked method declares or refers to in its
+// body, besides its results r0, r1 and so on.
+var bodyNames = []string{"mock", "args", "rf", "ok", "argOk", "_"}
+
+// methodParams returns the parameters of the mocked method fun, renaming any
+// that would clash with the identifiers of its body. Those named after a
+// package its types refer to would hide it, e.g. url *url.URL becomes
+// p0 *url.URL, and those named like the body's own variables would be hidden
+// by them, or redeclare them.
+func methodParams(fun *Func) []*Param {
+	taken := slices.Concat(qualifiers(slices.Concat(fun.Params, fun.Return)), bodyNames)
+	for i := range fun.Return {
+		taken = append(taken, fmt.Sprintf("r%d", i))
+	}
+
+	names := make([]string, 0, len(fun.Params))
+	for _, param := range fun.Params {
+		names = append(names, param.Name)
+	}
+
+	params := make([]*Param, 0, len(fun.Params))
+	for i, param := range fun.Params {
+		if contains(taken, param.Name) {
+			name := fmt.Sprintf("p%d", i)
+			for contains(names, name) {
+				name = "_" + name
+			}
+
+			param = &Param{Name: name, Type: param.Type}
+		}
+
+		params = append(params, param)
+	}
+
+	return params
+}
+
+// formatFuncType formats the type of a function with the given parameters
+// and results, e.g. func(int, ...string) (bool, error).
+func formatFuncType(params, results []*Param) string {
+	types := make([]string, 0)
+	for _, param := range params {
+		types = append(types, param.Type)
+	}
+
+	ret := "func(" + strings.Join(types, ", ") + ")"
+	switch len(results) {
+	case 0:
+		return ret
+	case 1:
+		return ret + " " + results[0].Type
+	}
+
+	types = make([]string, 0)
+	for _, result := range results {
+		types = append(types, result.Type)
+	}
+
+	return ret + " (" + strings.Join(types, ", ") + ")"
+}
+
+// formatRunArgs formats the arguments a Run function is called with, spreading
+// the final one when variadic.
+func formatRunArgs(params []*Param) string {
+	formatted := make([]string, 0)
+	for i, param := range params {
+		arg := fmt.Sprintf("a%d", i)
+		if strings.HasPrefix(param.Type, "...") {
+			arg += "..."
+		}
+
+		formatted = append(formatted, arg)
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// variadicSlice returns the slice type a variadic parameter is received as,
+// leaving other types alone.
+func variadicSlice(typ string) string {
+	if strings.HasPrefix(typ, "...") {
+		return "[]" + strings.TrimPrefix(typ, "...")
+	}
+
+	return typ
+}
+
+// interfaceRef returns how the mocks in tempData refer to the interface inter,
+// or an empty string if they can't because it's unexported from another
+// package, or refers to types that are.
+func interfaceRef(tempData *TemplateData, inter *Interface) string {
+	if tempData.SourcePackage == "" {
+		return inter.Name
+	}
+
+	if !token.IsExported(inter.Name) || len(unexportedRefs(inter.Generics, tempData.SourcePackage)) > 0 {
+		return ""
+	}
+
+	for _, fun := range inter.Funcs {
+		if len(unexportedRefs(slices.Concat(fun.Params, fun.Return), tempData.SourcePackage)) > 0 {
+			return ""
+		}
+	}
+
+	return tempData.SourcePackage + "." + inter.Name
+}
+
+// embeddedField returns the name of the field an embedded mock is promoted
+// through, e.g. pkg.MockBase[T] is MockBase.
+func embeddedField(embedded string) string {
+	embedded = strings.TrimPrefix(embedded, "*")
+	if i := strings.Index(embedded, "["); i >= 0 {
+		embedded = embedded[:i]
+	}
+
+	return embedded[strings.LastIndex(embedded, ".")+1:]
+}
+
+// escape escapes s for use inside a double quoted string literal.
+func escape(s string) string {
+	quoted := strconv.Quote(s)
+	return quoted[1 : len(quoted)-1]
+}
+
+func isEmptyOrWhitespace(s string) bool {
+	s = strings.ReplaceAll(s, " ", "")
+	return len(s) == 0
+}
@@ -0,0 +1,261 @@
+package generate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/types"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles |
+	packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
+
+// ParseTypes loads the package in dir with go/packages and builds the template
+// data for each of its non-mock files from the type checker rather than by
+// rendering the AST, so every type is written exactly as go/types sees it.
+// The returned template data is in the same order as the package paths. When
+// qualify is true the package's own types are qualified with its name, for
+// mocks written to another package.
+func ParseTypes(ctx context.Context, dir string, qualify bool) (*Package, []*TemplateData, error) {
+	pkgs, err := packages.Load(&packages.Config{Context: ctx, Mode: loadMode, Dir: dir}, ".")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if len(pkgs) != 1 {
+		return nil, nil, fmt.Errorf("expected 1 package in %s, found %d", dir, len(pkgs))
+	}
+
+	p := pkgs[0]
+	var pkgErr *ParseError
+	for _, e := range p.Errors {
+		// Stale mocks shouldn't stop them being regenerated
+		if strings.Contains(e.Pos, "_mock.go:") || strings.Contains(e.Msg, "_mock.go:") {
+			continue
+		}
+
+		// go list reports syntax errors without a position, so prefer the
+		// parser's
+		if pkgErr == nil || !pkgErr.Pos.IsValid() {
+			pkgErr = packageError(e)
+		}
+	}
+
+	if pkgErr != nil {
+		return nil, nil, pkgErr
+	}
+
+	pkg := &Package{Name: p.Name, Dir: dir, ImportPath: p.PkgPath, Fset: p.Fset}
+	data := make([]*TemplateData, 0)
+	declared := declaredNames(p.Syntax)
+	for _, f := range p.Syntax {
+		filename := p.Fset.File(f.Pos()).Name()
+		if strings.HasSuffix(filename, "_mock.go") {
+			continue
+		}
+
+		if qualify {
+			// Only the embedded type arguments are still rendered from the syntax
+			Qualify(f, p.Name, declared)
+		}
+
+		conv := &typeConverter{pkg: p.Types, info: p.TypesInfo, imports: map[string]string{}, qualify: qualify}
+		conv.syntax.fset = p.Fset
+		pkg.Paths = append(pkg.Paths, filepath.Join(dir, filepath.Base(filename)))
+		pkg.Files = append(pkg.Files, f)
+		data = append(data, conv.File(f))
+
+		if err := errors.Join(conv.syntax.errs...); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return pkg, data, nil
+}
+
+// typeConverter builds the template model from type checked declarations,
+// recording the imports needed by the rendered types.
+type typeConverter struct {
+	pkg     *types.Package
+	info    *types.Info
+	imports map[string]string
+	qualify bool
+	// syntax renders the embedded interfaces, which are kept as written
+	syntax fileParser
+}
+
+// File converts every interface declared at the top level of f.
+func (c *typeConverter) File(f *ast.File) *TemplateData {
+	tempData := &TemplateData{
+		Package:    c.pkg.Name(),
+		Interfaces: make([]*Interface, 0),
+		Imports:    make([]string, 0),
+	}
+
+	// The file's own imports are kept, as written, for the embedded interfaces
+	// rendered from the syntax, even when no method uses them
+	for _, impo := range f.Imports {
+		if pkgName := c.info.PkgNameOf(impo); pkgName != nil && pkgName.Name() != "_" && pkgName.Name() != "." {
+			c.imports[pkgName.Imported().Path()] = pkgName.Name()
+		}
+	}
+
+	for _, decl := range f.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok {
+			continue
+		}
+
+		for _, spec := range gen.Specs {
+			ts, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+
+			if inter := c.Interface(ts); inter != nil {
+				applyDirectives(inter, typeSpecDocs(gen, ts)...)
+				tempData.Interfaces = append(tempData.Interfaces, inter)
+			}
+		}
+	}
+
+	for p, name := range c.imports {
+		tempData.Imports = append(tempData.Imports, formatImport(name, p))
+	}
+	sort.Strings(tempData.Imports)
+
+	return tempData
+}
+
+// Interface converts the interface declared by ts, or returns nil if ts does
+// not declare an interface.
+func (c *typeConverter) Interface(ts *ast.TypeSpec) *Interface {
+	it, ok := ts.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil
+	}
+
+	obj, ok := c.info.Defs[ts.Name].(*types.TypeName)
+	if !ok {
+		return nil
+	}
+
+	inter := &Interface{Name: ts.Name.Name, Pos: c.syntax.position(ts.Name.Pos())}
+	c.syntax.inter = inter.Name
+	if iface, ok := obj.Type().Underlying().(*types.Interface); ok {
+		inter.Constraint = !iface.IsMethodSet() || iface.IsComparable()
+	}
+
+	if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+		inter.Generics = []*Param{}
+		for i := 0; i < named.TypeParams().Len(); i++ {
+			tp := named.TypeParams().At(i)
+			inter.Generics = append(inter.Generics, &Param{
+				Name: tp.Obj().Name(),
+				Type: types.TypeString(tp.Constraint(), c.qualifier),
+			})
+		}
+	}
+
+	for _, method := range it.Methods.List {
+		if len(method.Names) == 0 {
+			if isTypeElement(method.Type) {
+				inter.Constraint = true
+			} else if embedded := c.syntax.processEmbedded(method.Type); embedded != "" {
+				inter.Embedded = append(inter.Embedded, embedded)
+			}
+
+			continue
+		}
+
+		if fn, ok := c.info.Defs[method.Names[0]].(*types.Func); ok {
+			fun := c.Func(fn)
+			fun.Pos = c.syntax.position(method.Pos())
+			inter.Funcs = append(inter.Funcs, fun)
+		}
+	}
+
+	return inter
+}
+
+// Func converts a method into its name, parameters and results.
+func (c *typeConverter) Func(fn *types.Func) *Func {
+	sig := fn.Type().(*types.Signature)
+
+	return &Func{
+		Name:   fn.Name(),
+		Params: c.Params(sig.Params(), sig.Variadic()),
+		Return: c.Params(sig.Results(), false),
+	}
+}
+
+// Params converts a parameter or result tuple, rendering the final parameter
+// as variadic when requested.
+func (c *typeConverter) Params(tuple *types.Tuple, variadic bool) []*Param {
+	var params []*Param
+	for i := 0; i < tuple.Len(); i++ {
+		v := tuple.At(i)
+
+		typ := types.TypeString(v.Type(), c.qualifier)
+		if variadic && i == tuple.Len()-1 {
+			if slice, ok := v.Type().(*types.Slice); ok {
+				typ = "..." + types.TypeString(slice.Elem(), c.qualifier)
+			}
+		}
+
+		params = append(params, &Param{Name: v.Name(), Type: typ})
+	}
+
+	return params
+}
+
+// qualifier names packages other than the one being converted, or every
+// package when qualifying, recording each as an import. Packages already
+// imported keep their existing name, and a package whose name is taken by
+// another path is numbered, e.g. template1.
+func (c *typeConverter) qualifier(p *types.Package) string {
+	if p == c.pkg && !c.qualify {
+		return ""
+	}
+
+	if name, ok := c.imports[p.Path()]; ok {
+		return name
+	}
+
+	name := p.Name()
+	for i := 1; c.imported(name); i++ {
+		name = fmt.Sprintf("%s%d", p.Name(), i)
+	}
+
+	c.imports[p.Path()] = name
+	return name
+}
+
+// imported reports whether a package is already imported as name.
+func (c *typeConverter) imported(name string) bool {
+	for _, n := range c.imports {
+		if n == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatImport formats an import line, only aliasing the path when the package
+// name differs from its last element.
+func formatImport(name, importPath string) string {
+	if path.Base(importPath) == name {
+		return strconv.Quote(importPath)
+	}
+
+	return fmt.Sprintf("%s %s", name, strconv.Quote(importPath))
+}
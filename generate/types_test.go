@@ -0,0 +1,174 @@
+package generate
+
+import (
+	"context"
+	"go/token"
+	"go/types"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTypes(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+import (
+	"crypto/sha256"
+	"io"
+	stdtime "time"
+)
+
+type Event struct{}
+
+type Alias = map[string][]Event
+
+type Pair[A, B any] struct{}
+
+type Store[K comparable, V any] interface {
+	Events() <-chan Event
+	Send(ch chan<- int) error
+	Hash() [sha256.Size]byte
+	Stats() struct{ Hits, Misses int }
+	Do(x interface{ Close() error }) io.Reader
+	Nested(p Pair[K, Pair[V, string]]) Alias
+	Wait(d stdtime.Duration, keys ...K) (v V, ok bool)
+}
+
+func local() {
+	type Local interface{ Ignored() }
+}
+`,
+		"foo_mock.go": `package foo
+
+var _ = stale
+`,
+	})
+
+	pkg, data, err := ParseTypes(context.Background(), dir, false)
+	require.NoError(t, err)
+	assert.Equal(t, "foo", pkg.Name)
+	assert.Equal(t, []string{filepath.Join(dir, "foo.go")}, pkg.Paths)
+	require.Len(t, data, 1)
+
+	// Positions are checked on their own, the rest of the model is compared
+	// without them
+	store := data[0].Interfaces[0]
+	assert.Equal(t, filepath.Join(dir, "foo.go"), store.Pos.Filename)
+	assert.Equal(t, 15, store.Pos.Line)
+	assert.Equal(t, 16, store.Funcs[0].Pos.Line)
+	store.Pos = token.Position{}
+	for _, fun := range store.Funcs {
+		fun.Pos = token.Position{}
+	}
+
+	expectedData := &TemplateData{
+		Package: "foo",
+		Interfaces: []*Interface{
+			{
+				Name: "Store",
+				Funcs: []*Func{
+					{
+						Name: "Events",
+						Return: []*Param{
+							{Name: "", Type: "<-chan Event"},
+						},
+					},
+					{
+						Name: "Send",
+						Params: []*Param{
+							{Name: "ch", Type: "chan<- int"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "error"},
+						},
+					},
+					{
+						Name: "Hash",
+						Return: []*Param{
+							{Name: "", Type: "[32]byte"},
+						},
+					},
+					{
+						Name: "Stats",
+						Return: []*Param{
+							{Name: "", Type: "struct{Hits int; Misses int}"},
+						},
+					},
+					{
+						Name: "Do",
+						Params: []*Param{
+							{Name: "x", Type: "interface{Close() error}"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "io.Reader"},
+						},
+					},
+					{
+						Name: "Nested",
+						Params: []*Param{
+							{Name: "p", Type: "Pair[K, Pair[V, string]]"},
+						},
+						Return: []*Param{
+							{Name: "", Type: "Alias"},
+						},
+					},
+					{
+						Name: "Wait",
+						Params: []*Param{
+							{Name: "d", Type: "stdtime.Duration"},
+							{Name: "keys", Type: "...K"},
+						},
+						Return: []*Param{
+							{Name: "v", Type: "V"},
+							{Name: "ok", Type: "bool"},
+						},
+					},
+				},
+				Generics: []*Param{
+					{Name: "K", Type: "comparable"},
+					{Name: "V", Type: "any"},
+				},
+			},
+		},
+		Imports: []string{"\"crypto/sha256\"", "\"io\"", "stdtime \"time\""},
+	}
+	assert.Equal(t, expectedData, data[0])
+}
+
+func TestParseTypesImportClash(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"go.mod": "module example.com/foo\n\ngo 1.18\n",
+		"foo.go": `package foo
+
+import (
+	tmpl "html/template"
+	"text/template"
+)
+
+type Renderer interface {
+	Render(text *template.Template, html *tmpl.Template) error
+}
+`,
+	})
+
+	_, data, err := ParseTypes(context.Background(), dir, false)
+	require.NoError(t, err)
+	require.Len(t, data, 1)
+	assert.Equal(t, []string{"\"text/template\"", "tmpl \"html/template\""}, data[0].Imports)
+	assert.Equal(t, []*Param{
+		{Name: "text", Type: "*template.Template"},
+		{Name: "html", Type: "*tmpl.Template"},
+	}, data[0].Interfaces[0].Funcs[0].Params)
+
+	// Packages the file doesn't import are numbered when their name is taken
+	conv := &typeConverter{imports: map[string]string{"text/template": "template"}}
+	assert.Equal(t, "template1", conv.qualifier(types.NewPackage("html/template", "template")))
+	assert.Equal(t, "template1", conv.qualifier(types.NewPackage("html/template", "template")))
+	assert.Equal(t, "template2", conv.qualifier(types.NewPackage("example.com/template", "template")))
+}
@@ -1,597 +1,245 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
-	"log"
 	"os"
-	"path/filepath"
-	"reflect"
+	"slices"
 	"strings"
-	"text/template"
 
-	"golang.org/x/tools/imports"
+	"github.com/scottkgregory/ridicule/generate"
 )
 
-type TemplateData struct {
-	Package    string
-	Interfaces []*Interface
-	Imports    []string
-	Header     bool
-}
-
-type Interface struct {
-	Name     string
-	MockName string
-	Funcs    []*Func
-	Embedded []string
-	Generics []*Param
-}
-
-type Func struct {
-	Name   string
-	Params []*Param
-	Return []*Param
-}
+// Exit codes, distinguishing why generation failed
+const (
+	exitError       = 1
+	exitUsage       = 2
+	exitParse       = 3
+	exitUnsupported = 4
+	exitTemplate    = 5
+	exitStrict      = 6
+	exitOutOfDate   = 7
+)
 
-type Param struct {
-	Name string
-	Type string
+// runOptions are the flags controlling the run itself, rather than the mocks
+// generated.
+type runOptions struct {
+	// strict fails the run on warnings
+	strict bool
+	// check compares the mocks with those on disk rather than writing them
+	check bool
 }
 
 func main() {
-	in, out, header, ok := parseFlags()
-	if !ok {
-		fmt.Println("error: invalid flags: Invalid inputs, please provide at least the -in param")
-		return
-	}
-
-	file, err := os.ReadFile(in)
+	opts, run, err := parseFlags(os.Args[1:])
 	if err != nil {
-		fmt.Printf("error: reading file: %s\n", err)
-		return
+		fmt.Fprintf(os.Stderr, "error: invalid flags: %s\n", err)
+		os.Exit(exitUsage)
 	}
 
-	fset := token.NewFileSet()
-	parsedFile, err := parser.ParseFile(fset, "", string(file), parser.ParseComments)
-	if err != nil {
-		fmt.Printf("error: parsing file: %s\n", err)
-		return
+	warnings := 0
+	opts.Report = func(d generate.Diagnostic) {
+		warnings++
+		fmt.Fprintln(os.Stderr, d)
 	}
 
-	tempData := Parse(parsedFile)
-	tempData.Header = header
-
-	NewFileWriter().WriteMock(out, tempData)
-
-	fmt.Printf("debug: Generated '%s' interface mocks\n", in)
-}
-
-// parseFlags reads in and out from flags and returns them.
-func parseFlags() (in, out string, header, valid bool) {
-	flag.StringVar(&in, "in", "", "Source file")
-	flag.StringVar(&out, "out", "", "Destination file override")
-	flag.BoolVar(&header, "header", false, "Set to true to include the 'do not edit' header in files")
-	flag.Parse()
-
-	if out == "" {
-		out = filepath.Join(filepath.Dir(in), strings.ReplaceAll(filepath.Base(in), ".go", "_mock.go"))
+	packages := 0
+	opts.Processed = func(string) {
+		packages++
 	}
 
-	if in != "" && out != "" {
-		valid = true
-	}
-	return
-}
-
-func Parse(f *ast.File) *TemplateData {
-	tempData := &TemplateData{}
-
-	tempData.Package = f.Name.Name
-
-	tempData.Interfaces = make([]*Interface, 0)
-	ast.Inspect(f, func(n ast.Node) bool {
-		switch x := n.(type) {
-		// find variable declarations
-		case *ast.TypeSpec:
-			switch x.Type.(type) {
-			// and are interfaces
-			case *ast.InterfaceType:
-				inter := &Interface{Name: x.Name.Name}
-				if x.TypeParams != nil {
-					// handle generics
-					inter.Generics = []*Param{}
-					for _, tp := range x.TypeParams.List {
-						inter.Generics = append(inter.Generics, processExpr(tp.Type, []string{tp.Names[0].Name})...)
-					}
-				}
-
-				i := x.Type.(*ast.InterfaceType)
-
-				for _, method := range i.Methods.List {
-					fun := &Func{}
-
-					if len(method.Names) > 0 {
-						fun.Name = method.Names[0].Name
-					} else {
-						// Assume its an embedded interface
-
-						if ident, ok := method.Type.(*ast.Ident); ok {
-							inter.Embedded = append(inter.Embedded, "Mock"+ident.Name)
-						} else if sel, ok := method.Type.(*ast.SelectorExpr); ok {
-							parts := strings.Split(processSelectorExpr(sel), ".")
-							inter.Embedded = append(inter.Embedded, fmt.Sprintf("%s.Mock%s", parts[0], parts[1]))
-						} else if star, ok := method.Type.(*ast.StarExpr); ok {
-							inter.Embedded = append(inter.Embedded, "*Mock"+strings.Trim(processStarExpr(star), "*"))
-						}
-
-						continue
-					}
-
-					if funcType, ok := method.Type.(*ast.FuncType); ok {
-						for _, p := range funcType.Params.List {
-							params := getParams(p)
-							fun.Params = append(fun.Params, params...)
-						}
-
-						if funcType.Results != nil {
-							for _, r := range funcType.Results.List {
-								ret := getParams(r)
-								fun.Return = append(fun.Return, ret...)
-							}
-						}
-					}
-					inter.Funcs = append(inter.Funcs, fun)
-				}
-
-				tempData.Interfaces = append(tempData.Interfaces, inter)
-			}
-		}
-
-		tempData.Imports = make([]string, 0)
-		for _, impo := range f.Imports {
-			if impo.Name != nil {
-				tempData.Imports = append(tempData.Imports, fmt.Sprintf("%s %s", impo.Name.Name, impo.Path.Value))
-			} else {
-				tempData.Imports = append(tempData.Imports, impo.Path.Value)
-			}
-		}
-
-		return true
-	})
-
-	return tempData
-}
-
-func processExpr(e ast.Expr, names []string) []*Param {
-	params := make([]*Param, 0)
-	switch t := e.(type) {
-	case *ast.SelectorExpr:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: processSelectorExpr(t),
-			})
-		}
-
-		if len(names) == 0 {
-			params = append(params, &Param{Type: processSelectorExpr(t)})
-		}
-	case *ast.StarExpr:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: processStarExpr(t),
-			})
-		}
-
-		if len(names) == 0 {
-			params = append(params, &Param{Type: processStarExpr(t)})
-		}
-	case *ast.Ident:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: t.Name,
-			})
-		}
-
-		if len(names) == 0 {
-			params = append(params, &Param{Type: t.Name})
-		}
-	case *ast.MapType:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: processMapExpr(t),
-			})
-		}
-
-		if len(names) == 0 {
-			params = append(params, &Param{Type: processMapExpr(t)})
-		}
-	case *ast.InterfaceType:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: "interface{}",
-			})
-		}
-
-		if len(names) == 0 {
-			params = append(params, &Param{Type: "interface{}"})
-		}
-	case *ast.ArrayType:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: processArrayExpr(t),
-			})
-		}
-
-		if len(names) == 0 {
-			params = append(params, &Param{Type: processArrayExpr(t)})
-		}
-	case *ast.Ellipsis:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: processEllipsisExpr(t),
-			})
-		}
+	files, err := generate.Generate(context.Background(), *opts)
 
-		if len(names) == 0 {
-			params = append(params, &Param{Type: processEllipsisExpr(t)})
-		}
-	case *ast.FuncType:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: processFuncExpr(t),
-			})
-		}
+	// With -strict, warnings fail the run before any mocks are written
+	failed := run.strict && warnings > 0
 
-		if len(names) == 0 {
-			params = append(params, &Param{Type: processFuncExpr(t)})
-		}
-	case *ast.IndexListExpr:
-		for _, n := range names {
-			params = append(params, &Param{
-				Name: n,
-				Type: processIndexListExpr(t),
-			})
-		}
-
-		if len(names) == 0 {
-			params = append(params, &Param{
-				Type: processIndexListExpr(t),
-			})
+	outOfDate, ok := false, true
+	switch {
+	case failed:
+	case run.check:
+		if outOfDate, ok = checkFiles(files); !ok {
+			os.Exit(exitError)
 		}
 	default:
-		fmt.Println(fmt.Errorf("unknown type in param: %v %s", names, reflect.TypeOf(e)))
-	}
-
-	return params
-}
-
-func getParams(p *ast.Field) []*Param {
-	names := getNames(p)
-	params := processExpr(p.Type, names)
-
-	return params
-}
-
-func contains(arr []string, s string) bool {
-	for _, a := range arr {
-		if a == s {
-			return true
+		if werr := generate.WriteFiles(files); werr != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", werr)
+			os.Exit(exitError)
 		}
-	}
-
-	return false
-}
-
-func getNames(p *ast.Field) (ret []string) {
-	for _, n := range p.Names {
-		ret = append(ret, n.Name)
-	}
-
-	return ret
-}
-
-func processSelectorExpr(t *ast.SelectorExpr) (ret string) {
-	if ident, ok := t.X.(*ast.Ident); ok {
-		ret += ident.Name
-	}
-
-	return ret + "." + t.Sel.Name // context.Context
-}
-
-func processStarExpr(t *ast.StarExpr) (ret string) {
-	retArr := make([]string, 0)
-	for _, p := range processExpr(t.X, []string{}) {
-		x := "*"
-		x += p.Type
-		retArr = append(retArr, x)
-	}
-
-	return strings.Join(retArr, ", ") // []string
-}
-
-func processMapExpr(t *ast.MapType) (ret string) {
-	ret += "map["
-	for _, p := range processExpr(t.Key, []string{}) {
-		ret += p.Type
-	}
-
-	ret += "]"
-	for _, p := range processExpr(t.Value, []string{}) {
-		ret += p.Type
-	}
 
-	return ret // map[int]string
-}
-
-func processArrayExpr(t *ast.ArrayType) (ret string) {
-	retArr := make([]string, 0)
-	for _, p := range processExpr(t.Elt, []string{}) {
-		x := "[]"
-		x += p.Type
-		retArr = append(retArr, x)
-	}
-
-	return strings.Join(retArr, ", ") // []string
-}
-
-func processEllipsisExpr(t *ast.Ellipsis) (ret string) {
-	retArr := make([]string, 0)
-	for _, p := range processExpr(t.Elt, []string{}) {
-		str := "..."
-		if p.Name != "" {
-			str += p.Name + " "
+		for _, source := range sources(files) {
+			fmt.Fprintf(os.Stderr, "debug: Generated '%s' interface mocks\n", source)
 		}
-
-		str += p.Type
-		retArr = append(retArr, str)
 	}
 
-	return strings.Join(retArr, ", ") // ...Message
-}
-
-func processFuncExpr(t *ast.FuncType) (ret string) {
-	ret += "func("
-
-	for _, p := range t.Params.List {
-		for _, x := range getParams(p) {
-			ret = fmt.Sprintf("%s%s, ", ret, x.Type)
+	if err != nil {
+		for _, d := range generate.Diagnostics(err) {
+			fmt.Fprintln(os.Stderr, d)
 		}
-	}
 
-	ret = strings.Trim(ret, ", ")
-	ret += ")"
-
-	if len(t.Results.List) > 1 {
-		ret += "("
+		os.Exit(exitCode(err))
 	}
 
-	for _, p := range t.Results.List {
-		for _, x := range getParams(p) {
-			ret = fmt.Sprintf("%s%s %s, ", ret, x.Name, x.Type)
-		}
+	if failed {
+		fmt.Fprintln(os.Stderr, "error: failing on warnings with -strict, no mocks were written")
+		os.Exit(exitStrict)
 	}
 
-	ret = strings.Trim(ret, ", ")
-	if len(t.Results.List) > 1 {
-		ret += ")"
-	}
-
-	return ret // func(x string) (bool)
-}
+	summarize(opts, files, packages)
 
-func processIndexListExpr(t *ast.IndexListExpr) (ret string) {
-	retArr := make([]string, 0)
-	for _, i := range t.Indices {
-		for _, p := range processExpr(i, []string{}) {
-			retArr = append(retArr, p.Type)
-		}
+	if outOfDate {
+		fmt.Fprintln(os.Stderr, "error: mocks are out of date, run ridicule without -check to regenerate them")
+		os.Exit(exitOutOfDate)
 	}
-
-	return processExpr(t.X, []string{})[0].Type + "[" + strings.Join(retArr, ", ") + "]" // gen.Generic[name.Name, string]
 }
 
-var templateContent string = `{{- $global := . -}}
-{{- if .Header }}// Code generated by 'ridicule' DO NOT EDIT.
-//
-// ######   #####     ######   #####  #######    ####### ######  ####### #######
-// ####### #######    ####### ####### #######    ####### ####### ####### #######
-// ### ### ### ###    ### ### ### ###   ###      ###     ### ###   ###     ###
-// ### ### ### ###    ### ### ### ###   ###      ####### ### ###   ###     ###
-// ### ### ### ###    ### ### ### ###   ###      ###     ### ###   ###     ###
-// ####### #######    ### ### #######   ###      ####### ####### #######   ###
-// ######   #####     ### ###  #####    ###      ####### ######  #######   ###
-//
-// *** DO NOT EDIT *** This file was generated by 'ridicule' *** DO NOT EDIT ***
-
-{{end}}package {{ .Package }}
-
-import (
-	"github.com/stretchr/testify/mock"
-	{{- range .Imports }}
-	{{ . }}
-	{{- end }}
-)
-{{ range $interface := .Interfaces }}
-// {{ $interface.MockName }} mocks the {{ $interface.Name }} interface
-type {{ $interface.MockName }}{{if len $interface.Generics }}[{{ formatParams $interface.Generics "" }}]{{end}} struct {
-	mock.Mock
-	{{- range .Embedded }}
-	{{ . }}
-	{{- end }}
-}
-{{- end }}
-{{- range $interface := .Interfaces }}
-{{- range $f := $interface.Funcs }}
-
-// {{ $f.Name }} mocks the {{ $f.Name }} function
-func (mock *{{ $interface.MockName }}{{if len $interface.Generics }}[{{ formatGenerics $interface.Generics }}]{{end}}) {{ $f.Name }}({{ formatParams $f.Params "p" }}){{ formatReturnParams $f.Return }} {
-	{{- if not $f.Return }}
-	mock.Called({{ formatNames $f.Params }})
-	{{- else }}
-	args := mock.Called({{ formatNames $f.Params }})
-	{{- end }}
-	{{- range $i, $r := $f.Return }}
-
-	if args.Get({{ $i }}) != nil {
-		argOk := false
-		r{{ $i }}, argOk = args.Get({{ $i }}).({{ $r.Type }})
-		if !argOk {
-			panic("incorrect type supplied for return value [{{ $i }}], expected {{ $r.Type }}")
+// sources returns the distinct sources of files, in order.
+func sources(files []generate.GeneratedFile) []string {
+	ret := make([]string, 0)
+	for _, f := range files {
+		if !slices.Contains(ret, f.Source) {
+			ret = append(ret, f.Source)
 		}
 	}
-	{{- end }}{{ if $f.Return }}
-	return {{ formatReturn $f.Return }}{{- end }}
-}
-{{- end }}
-{{- end }}
-`
-
-type FileWriter struct {
-	template *template.Template
-}
-
-func NewFileWriter() *FileWriter {
-	funcMap := template.FuncMap{
-		"add": func(x, y int) int {
-			return x + y
-		},
-		"formatParams":       formatParams,
-		"formatGenerics":     formatGenerics,
-		"formatReturnParams": formatReturnParams,
-		"formatNames":        formatNames,
-		"formatReturn":       formatReturn,
-	}
-	template := template.Must(
-		template.New("mock.tmpl").Funcs(funcMap).Parse(templateContent),
-	)
 
-	return &FileWriter{template}
+	return ret
 }
 
-func (f *FileWriter) WriteMock(outPath string, tempData *TemplateData) {
-	out, err := writeMock(tempData, f, outPath)
-	if err != nil {
-		log.Fatalf("error writing mock: %s", err)
+// summarize prints how many of the packages processed matching opts.Patterns
+// mocks were generated for, or that no interfaces were found in the source
+// given.
+func summarize(opts *generate.Options, files []generate.GeneratedFile, packages int) {
+	if len(opts.Patterns) > 0 {
+		fmt.Fprintf(os.Stderr, "debug: Processed %d packages, generated mocks for %d\n", packages, len(sources(files)))
 		return
 	}
 
-	err = os.WriteFile(outPath, out, 0o600)
-	if err != nil {
-		log.Fatalf("error writing file: %s", err)
+	if len(files) > 0 {
 		return
 	}
-}
-
-func writeMock(tempData *TemplateData, file *FileWriter, outPath string) ([]byte, error) {
-	for _, inter := range tempData.Interfaces {
-		inter.MockName = fmt.Sprintf("Mock%s", inter.Name)
-	}
 
-	var buff bytes.Buffer
-	err := file.template.Execute(&buff, tempData)
-	if err != nil {
-		log.Fatalf("error templating file: %s", err)
-		return nil, err
-	}
-
-	out, err := imports.Process(filepath.Base(outPath), buff.Bytes(), &imports.Options{Comments: true})
-	if err != nil {
-		log.Printf("error tidying imports: %s", err)
-		out = buff.Bytes()
+	switch {
+	case opts.Pkg != "":
+		fmt.Fprintf(os.Stderr, "debug: No interfaces found in '%s'\n", opts.Pkg)
+	case opts.In != "":
+		fmt.Fprintf(os.Stderr, "debug: No interfaces found in '%s'\n", opts.In)
+	default:
+		fmt.Fprintln(os.Stderr, "debug: No interfaces found")
 	}
-
-	return out, err
 }
 
-func formatParams(params []*Param, prefix string) string {
-	formatted := make([]string, 0)
-	for i, param := range params {
-		p := []string{}
-		if !isEmptyOrWhitespace(param.Name) {
-			p = append(p, param.Name)
-		} else {
-			p = append(p, fmt.Sprintf("%s%d", prefix, i))
-		}
-		if !isEmptyOrWhitespace(param.Type) {
-			p = append(p, param.Type)
+// checkFiles prints a diff for each generated file that differs from the one
+// on disk, reporting whether any do. ok is false when a file can't be read.
+func checkFiles(files []generate.GeneratedFile) (outOfDate, ok bool) {
+	for _, f := range files {
+		diff, err := f.Diff()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			return outOfDate, false
 		}
 
-		formatted = append(formatted, strings.Join(p, " "))
-	}
-
-	return strings.Join(formatted, ", ")
-}
-
-func formatGenerics(params []*Param) string {
-	formatted := make([]string, 0)
-	for _, param := range params {
-		formatted = append(formatted, param.Name)
+		if diff != "" {
+			outOfDate = true
+			fmt.Print(diff)
+		}
 	}
 
-	return strings.Join(formatted, ", ")
+	return outOfDate, true
 }
 
-func formatReturnParams(params []*Param) string {
-	formatted := make([]string, 0)
-	for i, param := range params {
-		paramStr := []string{}
-		paramStr = append(paramStr, fmt.Sprintf("r%d", i))
-		if !isEmptyOrWhitespace(param.Type) {
-			paramStr = append(paramStr, param.Type)
-		}
+// exitCode returns the exit code for an error returned by generate.Generate.
+func exitCode(err error) int {
+	var (
+		parseErr       *generate.ParseError
+		unsupportedErr *generate.UnsupportedTypeError
+		templateErr    *generate.TemplateError
+	)
 
-		formatted = append(formatted, strings.Join(paramStr, " "))
+	switch {
+	case errors.As(err, &parseErr):
+		return exitParse
+	case errors.As(err, &unsupportedErr):
+		return exitUnsupported
+	case errors.As(err, &templateErr):
+		return exitTemplate
+	default:
+		return exitError
+	}
+}
+
+// parseFlags reads the options from the command line arguments args and
+// returns them, along with those of the run itself, or an error if they don't
+// make sense together.
+func parseFlags(args []string) (opts *generate.Options, run runOptions, err error) {
+	flags := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	opts = &generate.Options{}
+	defaults := generate.DefaultOptions()
+	flags.StringVar(&opts.In, "in", "", "Source file, or - to read it from stdin")
+	flags.StringVar(&opts.PkgName, "pkgname", "", "Package of a source without a package clause, such as a snippet read from stdin with -in -")
+	flags.StringVar(&opts.Out, "out", "", "Destination file override, or - to write the mocks to stdout, as they are by default with -in -")
+	flags.StringVar(&opts.Pkg, "pkg", "", "Source package directory, mocks every interface in the package")
+	flags.BoolVar(&opts.Header, "header", false, "Set to true to include the 'do not edit' header in files")
+	flags.BoolVar(&opts.PerFile, "per-file", false, "Set to true to write one mock file per source file when using -pkg")
+	flags.BoolVar(&opts.Flatten, "flatten", defaults.Flatten, "Set to false to embed the mocks of embedded interfaces rather than generating their methods on the outer mock")
+	flags.BoolVar(&opts.Types, "types", false, "Set to true to build mocks from the type checked package rather than the syntax tree")
+	flags.Func("interfaces", "Comma separated names of the interfaces to mock, as globs or /regular expressions/", func(s string) error {
+		opts.Interfaces = append(opts.Interfaces, splitList(s)...)
+		return nil
+	})
+	flags.Func("exclude", "Comma separated names of interfaces not to mock, as globs or /regular expressions/", func(s string) error {
+		opts.Exclude = append(opts.Exclude, splitList(s)...)
+		return nil
+	})
+	flags.StringVar(&opts.OutPkg, "out-pkg", "", "Package to write the mocks to, e.g. mocks or foo_test, defaults to the name of -out-dir")
+	flags.StringVar(&opts.OutDir, "out-dir", "", "Directory to write the mocks to, relative to the source package, defaults to the source directory for _test packages or a directory named after -out-pkg")
+	flags.StringVar(&opts.Naming, "name", defaults.Naming, "Template the mocks are named with, e.g. {{.Name}}Mock or mock{{.Name}}")
+	flags.BoolVar(&opts.Constructor, "constructor", defaults.Constructor, "Set to false to skip generating New constructors that assert the expectations of each mock once the test finishes")
+	flags.BoolVar(&opts.Expecter, "expecter", false, "Set to true to generate typed builders for the expectations of each mock, set through EXPECT()")
+	flags.BoolVar(&opts.Assert, "assert", defaults.Assert, "Set to false to skip asserting at compile time that each mock implements its interface")
+	flags.BoolVar(&run.strict, "strict", false, "Set to true to fail without writing any mocks when warnings are reported")
+	flags.BoolVar(&run.check, "check", false, "Set to true to check the mocks on disk are up to date, printing a diff of any that aren't, rather than writing them")
+	flags.StringVar(&opts.Config, "config", "", "Configuration file describing every mock to generate, defaults to "+generate.ConfigFile+" at the module root when no other inputs are given")
+	if err := flags.Parse(args); err != nil {
+		return nil, run, err
+	}
+
+	opts.Patterns = flags.Args()
+	if opts.Config == "" && opts.In == "" && opts.Pkg == "" && len(opts.Patterns) == 0 {
+		opts.Config = generate.FindConfig(".")
+	}
+
+	valid := false
+	switch {
+	case opts.Config != "":
+		valid = opts.In == "" && opts.Pkg == "" && opts.Out == "" && len(opts.Patterns) == 0
+	case len(opts.Patterns) > 0:
+		valid = opts.In == "" && opts.Pkg == "" && opts.Out == ""
+	case opts.Pkg != "":
+		valid = opts.In == ""
+	default:
+		valid = opts.In != ""
 	}
 
-	formattedStr := strings.Join(formatted, ", ")
-
-	if formattedStr == "" {
-		return ""
+	if !valid {
+		return nil, run, errors.New("Invalid inputs, please provide at least the -in or -pkg param, a package pattern such as ./..., or a " + generate.ConfigFile + " file")
 	}
 
-	if strings.Contains(formattedStr, " ") {
-		return " (" + formattedStr + ")"
+	if run.check && (opts.Out == generate.Stdio || (opts.In == generate.Stdio && opts.Out == "")) {
+		return nil, run, errors.New("-check compares the mocks with those on disk, so can't be used when writing them to stdout")
 	}
 
-	return " " + formattedStr
+	return opts, run, nil
 }
 
-func formatNames(params []*Param) string {
-	formatted := make([]string, 0)
-	for i, param := range params {
-		if param.Name != "" {
-			formatted = append(formatted, param.Name)
-		} else {
-			formatted = append(formatted, fmt.Sprintf("p%d", i))
+// splitList splits a comma separated flag value, dropping empty entries.
+func splitList(s string) []string {
+	ret := make([]string, 0)
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			ret = append(ret, v)
 		}
 	}
 
-	return strings.Join(formatted, ", ")
-}
-
-func formatReturn(params []*Param) string {
-	formatted := make([]string, 0)
-	for i := range params {
-		formatted = append(formatted, fmt.Sprintf("r%d", i))
-	}
-
-	return strings.Join(formatted, ", ")
-}
-
-func isEmptyOrWhitespace(s string) bool {
-	s = strings.ReplaceAll(s, " ", "")
-	return len(s) == 0
+	return ret
 }